@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+)
+
+const (
+	StatusRequested = "requested"
+	StatusConfirmed = "confirmed"
+	StatusDeclined  = "declined"
+	StatusCancelled = "cancelled"
+
+	// activeStatusCond limits queries on entries to the ones occupying the
+	// house.
+	activeStatusCond = "e.status in ('requested', 'confirmed')"
+)
+
+var GermanStatus = map[string]string{
+	StatusRequested: "angefragt",
+	StatusConfirmed: "bestätigt",
+	StatusDeclined:  "abgelehnt",
+	StatusCancelled: "storniert",
+}
+
+type ApprovalRule struct {
+	ID         int
+	ResourceID int // 0 for all resources
+	Period
+}
+
+func (r ApprovalRule) appliesTo(entry Entry) bool {
+	if r.ResourceID != 0 && r.ResourceID != entry.ResourceID {
+		return false
+	}
+	return r.Overlaps(entry.Begin, entry.End)
+}
+
+func requiresApproval(rules []ApprovalRule, entry Entry) bool {
+	for _, r := range rules {
+		if r.appliesTo(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+func LoadApprovalRules() ([]ApprovalRule, error) {
+	rows, err := middleware.DB.Query("select id, label, start_md, end_md, coalesce(resource_id, 0) from approval_rules order by start_md")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching approval rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []ApprovalRule{}
+	for rows.Next() {
+		var r ApprovalRule
+		var label, start, end string
+		err = rows.Scan(&r.ID, &label, &start, &end, &r.ResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning approval rule: %w", err)
+		}
+		r.Period, err = ParsePeriod(label, start, end)
+		if err != nil {
+			log.Default().Printf("ignoring approval rule %d: %s", r.ID, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching approval rules: %w", rows.Err())
+	}
+	return rules, nil
+}
+
+func CreateApprovalRule(rule ApprovalRule) error {
+	var resourceID any
+	if rule.ResourceID != 0 {
+		resourceID = rule.ResourceID
+	}
+	_, err := middleware.DB.Exec("insert into approval_rules (label, start_md, end_md, resource_id) values (?,?,?,?)", rule.Label, rule.Start.String(), rule.End.String(), resourceID)
+	if err != nil {
+		return fmt.Errorf("error inserting approval rule: %w", err)
+	}
+	Audit(AuditCreate, "approval_rule", 0, nil, rule)
+	return nil
+}
+
+func DeleteApprovalRule(id int) error {
+	_, err := middleware.DB.Exec("delete from approval_rules where id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting approval rule (%d): %w", id, err)
+	}
+	Audit(AuditDelete, "approval_rule", id, nil, nil)
+	return nil
+}
+
+// initialStatus decides whether a new entry is final or has to be approved.
+func initialStatus(entry Entry) (string, error) {
+	rules, err := LoadApprovalRules()
+	if err != nil {
+		return "", err
+	}
+	if requiresApproval(rules, entry) {
+		return StatusRequested, nil
+	}
+	return StatusConfirmed, nil
+}
+
+// SetEntryStatus moves an entry through its lifecycle. Only admins may
+// confirm or decline requests, owners and their household may cancel entries.
+func SetEntryStatus(id int, status string, user string) error {
+	entry, err := LoadEntry(id)
+	if err != nil {
+		return err
+	}
+	actor, err := LoadUser(user)
+	if err != nil {
+		return err
+	}
+	if !statusChangeAllowed(entry, status, actor) {
+		Audit(AuditDenied, "entry", id, entry, map[string]string{"status": status})
+		return fmt.Errorf("user %s may not change status of entry %d from %s to %s: %w", user, id, entry.Status, status, ErrForbidden)
+	}
+	_, err = middleware.DB.Exec("update entries set status = ? where res_id = ?", status, id)
+	if err != nil {
+		return fmt.Errorf("error updating status of entry (%d): %w", id, err)
+	}
+	Audit(AuditStatus, "entry", id, map[string]string{"status": entry.Status}, map[string]string{"status": status})
+	if status == StatusDeclined || status == StatusCancelled {
+		processWaitlist(entry.ResourceID, entry.Begin, entry.End)
+	}
+	return nil
+}
+
+func statusChangeAllowed(entry Entry, status string, actor User) bool {
+	switch status {
+	case StatusConfirmed, StatusDeclined:
+		return actor.IsAdmin() && entry.Status == StatusRequested
+	case StatusCancelled:
+		return (actor.IsAdmin() || ownedBy(entry, actor.Name, actor.Household) || bookedOnBehalf(entry, actor)) && entry.IsActive()
+	}
+	return false
+}
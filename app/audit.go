@@ -0,0 +1,163 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	AuditCreate       = "create"
+	AuditUpdate       = "update"
+	AuditDelete       = "delete"
+	AuditStatus       = "status"
+	AuditDenied       = "denied"
+	AuditLogin        = "login"
+	AuditLoginFailed  = "login_failed"
+	AuditSwap         = "swap"
+	AuditSkip         = "skip_occurrence"
+	AuditMaterialize  = "materialize"
+	AuditLotteryDrawn = "lottery_drawn"
+	AuditRestore      = "restore"
+	AuditPurge        = "purge"
+)
+
+type AuditRecord struct {
+	ID         int
+	CreatedAt  time.Time
+	Actor      string
+	IP         string
+	Action     string
+	ObjectType string
+	ObjectID   int
+	Before     string // JSON snapshot, empty for creations
+	After      string // JSON snapshot, empty for deletions
+}
+
+// Audit appends a record for the logged in user. before and after are
+// stored as JSON, nil values as NULL. Like notifications, failing to audit is
+// logged but never fails the audited action.
+func Audit(action, objectType string, objectID int, before, after any) {
+	actor := middleware.Session.Get("username")
+	if actor == "" {
+		actor = "-"
+	}
+	_, err := middleware.DB.Exec("insert into audit_log (created_at, actor, ip, action, object_type, object_id, before_json, after_json) values (?,?,?,?,?,?,?,?)",
+		time.Now(), actor, os.Getenv(middleware.EnvREMOTEADDR), action, objectType, objectID, snapshot(before), snapshot(after))
+	if err != nil {
+		log.Default().Printf("error auditing %s of %s %d: %s", action, objectType, objectID, err)
+	}
+}
+
+func snapshot(v any) sql.NullString {
+	if v == nil {
+		return sql.NullString{}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Default().Printf("error creating audit snapshot: %s", err)
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(b), Valid: true}
+}
+
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	ObjectType string
+	ObjectID   int
+	From       time.Time
+	To         time.Time // inclusive, unlimited if zero
+	Page       int       // 1-based
+	PageSize   int
+}
+
+type AuditPage struct {
+	Records   []AuditRecord
+	Total     int
+	Page      int
+	PageCount int
+	PrevPage  int
+	NextPage  int
+}
+
+func (f AuditFilter) where() (string, []any) {
+	conds := []string{"1=1"}
+	args := []any{}
+	if f.Actor != "" {
+		conds = append(conds, "actor = ?")
+		args = append(args, f.Actor)
+	}
+	if f.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, f.Action)
+	}
+	if f.ObjectType != "" {
+		conds = append(conds, "object_type = ?")
+		args = append(args, f.ObjectType)
+	}
+	if f.ObjectID != 0 {
+		conds = append(conds, "object_id = ?")
+		args = append(args, f.ObjectID)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, f.To.AddDate(0, 0, 1))
+	}
+	return strings.Join(conds, " and "), args
+}
+
+// LoadAuditLog returns a page of records matching the filter, newest first.
+func LoadAuditLog(filter AuditFilter) (AuditPage, error) {
+	if filter.PageSize <= 0 {
+		filter.PageSize = DefaultPageSize
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	where, args := filter.where()
+	var total int
+	err := middleware.DB.QueryRow("select count(*) from audit_log where "+where, args...).Scan(&total)
+	if err != nil {
+		return AuditPage{}, fmt.Errorf("error counting audit records: %w", err)
+	}
+
+	args = append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	rows, err := middleware.DB.Query("select id, created_at, actor, ip, action, object_type, object_id, coalesce(before_json, ''), coalesce(after_json, '') from audit_log where "+where+" order by id desc limit ? offset ?", args...)
+	if err != nil {
+		return AuditPage{}, fmt.Errorf("error fetching audit records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []AuditRecord{}
+	for rows.Next() {
+		var r AuditRecord
+		err = rows.Scan(&r.ID, &r.CreatedAt, &r.Actor, &r.IP, &r.Action, &r.ObjectType, &r.ObjectID, &r.Before, &r.After)
+		if err != nil {
+			return AuditPage{}, fmt.Errorf("error scanning audit record: %w", err)
+		}
+		records = append(records, r)
+	}
+	if rows.Err() != nil {
+		return AuditPage{}, fmt.Errorf("error fetching audit records: %w", rows.Err())
+	}
+
+	entries := newEntryPage(nil, total, filter.Page, filter.PageSize)
+	return AuditPage{
+		Records:   records,
+		Total:     total,
+		Page:      entries.Page,
+		PageCount: entries.PageCount,
+		PrevPage:  entries.PrevPage,
+		NextPage:  entries.NextPage,
+	}, nil
+}
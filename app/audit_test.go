@@ -0,0 +1,39 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuditFilterWhere(t *testing.T) {
+	where, args := AuditFilter{}.where()
+	if where != "1=1" || len(args) != 0 {
+		t.Errorf("unexpected empty filter %q %v", where, args)
+	}
+
+	where, args = AuditFilter{
+		Actor:      "anna",
+		Action:     AuditDelete,
+		ObjectType: "entry",
+		ObjectID:   7,
+		From:       date(2024, 5, 1),
+		To:         date(2024, 5, 31),
+	}.where()
+	want := "1=1 and actor = ? and action = ? and object_type = ? and object_id = ? and created_at >= ? and created_at < ?"
+	if where != want {
+		t.Errorf("expected %q, got %q", want, where)
+	}
+	wantArgs := []any{"anna", AuditDelete, "entry", 7, date(2024, 5, 1), date(2024, 6, 1)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("expected %v, got %v", wantArgs, args)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	if s := snapshot(nil); s.Valid {
+		t.Error("expected NULL for nil")
+	}
+	if s := snapshot(map[string]string{"status": StatusCancelled}); !s.Valid || s.String != `{"status":"cancelled"}` {
+		t.Errorf("unexpected snapshot %+v", s)
+	}
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"franklyner/gores/middleware"
+	"time"
+)
+
+const (
+	BlockMaintenance = "maintenance"
+	BlockClosed      = "closed"
+
+	ClassnameBlocked = "blocked"
+)
+
+var (
+	ErrBlocked = errors.New("resource blocked")
+
+	GermanBlockKinds = map[string]string{
+		BlockMaintenance: "Unterhalt",
+		BlockClosed:      "Geschlossen",
+	}
+)
+
+// Block closes a resource from Begin to End (both inclusive). Blocks are not
+// reservations: they conflict with every booking and do not count as booked
+// nights.
+type Block struct {
+	ID           int
+	ResourceID   int
+	ResourceName string
+	Begin        time.Time
+	End          time.Time
+	Kind         string
+	Reason       string
+	CreatedBy    string
+	Recurrence   string // RRULE, empty for single blocks
+}
+
+func (b Block) KindName() string {
+	return GermanBlockKinds[b.Kind]
+}
+
+func (b Block) covers(day time.Time) bool {
+	return !day.Before(b.Begin) && !day.After(b.End)
+}
+
+func (b Block) overlaps(begin, end time.Time) bool {
+	return !b.Begin.After(end) && !b.End.Before(begin)
+}
+
+const blockColumns = "b.id, b.resource_id, coalesce(r.name, ''), b.begin, b.end, b.kind, b.reason, b.created_by, b.rrule"
+
+func queryBlocks(where string, args ...any) ([]Block, error) {
+	rows, err := middleware.DB.Query("select "+blockColumns+" from blocks b left join resources r on r.id = b.resource_id where "+where+" order by b.begin", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching blocks: %w", err)
+	}
+	defer rows.Close()
+
+	blocks := []Block{}
+	for rows.Next() {
+		var b Block
+		err = rows.Scan(&b.ID, &b.ResourceID, &b.ResourceName, &b.Begin, &b.End, &b.Kind, &b.Reason, &b.CreatedBy, &b.Recurrence)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning block: %w", err)
+		}
+		blocks = append(blocks, b)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching blocks: %w", rows.Err())
+	}
+	return blocks, nil
+}
+
+// loadBlocks loads all blocks of the given resources overlapping the range,
+// recurring blocks expanded into their occurrences.
+func loadBlocks(resourceIDs []int, start, end time.Time) ([]Block, error) {
+	in, args := inClause(resourceIDs)
+	args = append(args, start, end)
+	blocks, err := queryBlocks("b.resource_id in "+in+" and (b.end >= ? or b.rrule <> '') and b.begin <= ?", args...)
+	if err != nil {
+		return nil, err
+	}
+	return expandBlocks(blocks, start, end)
+}
+
+// LoadUpcomingBlocks returns all blocks which have not ended before from,
+// recurring blocks as a single series.
+func LoadUpcomingBlocks(from time.Time) ([]Block, error) {
+	return queryBlocks("(b.end >= ? or b.rrule <> '')", from)
+}
+
+func (b Block) RecurrenceText() string {
+	r, err := ParseRule(b.Recurrence)
+	if err != nil {
+		return ""
+	}
+	return r.Text()
+}
+
+// CreateBlock stores the block. Existing entries are left alone, the active
+// ones overlapping the block (up to the recurrence horizon) are returned so
+// they can be sorted out with their owners.
+func CreateBlock(block Block) ([]Entry, error) {
+	if block.End.Before(block.Begin) {
+		return nil, fmt.Errorf("block ends before it begins: %w", ErrInvalid)
+	}
+	if _, ok := GermanBlockKinds[block.Kind]; !ok {
+		block.Kind = BlockMaintenance
+	}
+	resource, resources, err := ResolveResource(block.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = middleware.DB.Exec("insert into blocks (resource_id, begin, end, kind, reason, created_by, rrule) values (?,?,?,?,?,?,?)", resource.ID, block.Begin, block.End, block.Kind, block.Reason, block.CreatedBy, block.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting block: %w", err)
+	}
+	Audit(AuditCreate, "block", 0, nil, block)
+	to := block.End
+	if block.Recurrence != "" {
+		to = recurrenceHorizon(time.Now())
+	}
+	occurrences := block.occurrences(block.Begin, to, nil)
+	if len(occurrences) == 0 {
+		return nil, nil
+	}
+	entries, err := loadEntries(conflictScope(resources, resource.ID), block.Begin, occurrences[len(occurrences)-1].End)
+	if err != nil {
+		return nil, err
+	}
+	affected := []Entry{}
+	for _, e := range entries {
+		for _, b := range occurrences {
+			if b.overlaps(e.Begin, e.End) {
+				affected = append(affected, e)
+				break
+			}
+		}
+	}
+	return affected, nil
+}
+
+// DeleteBlock removes the block and offers the freed days to the waitlist,
+// for recurring blocks every occurrence up to the recurrence horizon.
+func DeleteBlock(id int) error {
+	blocks, err := queryBlocks("b.id = ?", id)
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return fmt.Errorf("no block found (%d): %w", id, ErrNotFound)
+	}
+	now := time.Now()
+	freed, err := expandBlocks(blocks, now, recurrenceHorizon(now))
+	if err != nil {
+		return err
+	}
+	_, err = middleware.DB.Exec("delete from blocks where id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting block (%d): %w", id, err)
+	}
+	Audit(AuditDelete, "block", id, blocks[0], nil)
+	for _, b := range freed {
+		processWaitlist(b.ResourceID, b.Begin, b.End)
+	}
+	return nil
+}
+
+// applyBlocks marks the blocked days in the weeks. Blocks take precedence
+// over entries.
+func applyBlocks(weeks []Week, blocks []Block) {
+	for _, w := range weeks {
+		for i := range w.Days {
+			for _, b := range blocks {
+				if b.covers(w.Days[i].Date) {
+					w.Days[i].Block = b
+					w.Days[i].Classname = ClassnameBlocked + " " + b.Kind
+					w.Days[i].Color = ""
+					break
+				}
+			}
+		}
+	}
+}
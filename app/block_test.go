@@ -0,0 +1,56 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlockOverlaps(t *testing.T) {
+	b := Block{Begin: date(2024, 5, 10), End: date(2024, 5, 12)}
+	tests := []struct {
+		begin, end time.Time
+		want       bool
+	}{
+		{date(2024, 5, 1), date(2024, 5, 9), false},
+		{date(2024, 5, 1), date(2024, 5, 10), true},
+		{date(2024, 5, 11), date(2024, 5, 11), true},
+		{date(2024, 5, 12), date(2024, 5, 20), true},
+		{date(2024, 5, 13), date(2024, 5, 20), false},
+		{date(2024, 5, 1), date(2024, 5, 31), true},
+	}
+	for i, tt := range tests {
+		if got := b.overlaps(tt.begin, tt.end); got != tt.want {
+			t.Errorf("case %d: expected %t", i, tt.want)
+		}
+	}
+}
+
+func TestApplyBlocks(t *testing.T) {
+	entries := []Entry{
+		{User: "anna", Begin: date(2024, 5, 6), End: date(2024, 5, 8), Color: "#ff8800"},
+	}
+	blocks := []Block{
+		{ID: 4, Begin: date(2024, 5, 8), End: date(2024, 5, 9), Kind: BlockMaintenance},
+	}
+	weeks := buildWeeks(2024, 5, time.Monday, entries)
+	applyBlocks(weeks, blocks)
+
+	days := map[time.Time]Day{}
+	for _, w := range weeks {
+		for _, d := range w.Days {
+			days[d.Date] = d
+		}
+	}
+	if d := days[date(2024, 5, 7)]; d.Block.ID != 0 || d.Classname != ClassenamRightMonthEntry {
+		t.Errorf("expected booked day, got %q", d.Classname)
+	}
+	for _, day := range []time.Time{date(2024, 5, 8), date(2024, 5, 9)} {
+		d := days[day]
+		if d.Block.ID != 4 || d.Classname != "blocked maintenance" || d.Color != "" {
+			t.Errorf("%s: expected blocked day, got %q %q", day.Format(time.DateOnly), d.Classname, d.Color)
+		}
+	}
+	if d := days[date(2024, 5, 10)]; d.Block.ID != 0 {
+		t.Error("expected day after block to be free")
+	}
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"strings"
+	"time"
+)
+
+var GermanWeekdays = map[time.Weekday]string{
+	time.Sunday:    "So",
+	time.Monday:    "Mo",
+	time.Tuesday:   "Di",
+	time.Wednesday: "Mi",
+	time.Thursday:  "Do",
+	time.Friday:    "Fr",
+	time.Saturday:  "Sa",
+}
+
+type Week struct {
+	Number int // ISO 8601 week number
+	Days   []Day
+}
+
+// ParseWeekday accepts english weekday names ("monday", "Sunday") and falls
+// back to Monday for anything it does not understand.
+func ParseWeekday(s string) time.Weekday {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.ToLower(wd.String()) == s {
+			return wd
+		}
+	}
+	return time.Monday
+}
+
+// gridRange returns the first day shown in the month grid and the number of
+// weeks needed to show every day of the month (4 to 6).
+func gridRange(year, month int, firstWeekday time.Weekday) (time.Time, int) {
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(first.Weekday()) - int(firstWeekday) + 7) % 7
+	start := first.AddDate(0, 0, -offset)
+	weeks := (offset + daysIn(time.Month(month), year) + 6) / 7
+	return start, weeks
+}
+
+func buildWeeks(year, month int, firstWeekday time.Weekday, entries []Entry) []Week {
+	start, count := gridRange(year, month, firstWeekday)
+	// the monday of a row decides its ISO week number
+	mondayOffset := (int(time.Monday) - int(firstWeekday) + 7) % 7
+
+	weeks := make([]Week, 0, count)
+	currDay := start
+	for w := 0; w < count; w++ {
+		_, number := start.AddDate(0, 0, w*7+mondayOffset).ISOWeek()
+		week := Week{
+			Number: number,
+			Days:   make([]Day, 0, 7),
+		}
+		for i := 0; i < 7; i++ {
+			d := Day{
+				Date:       currDay,
+				DayOfMonth: currDay.Day(),
+				Month:      month,
+			}
+			entry, hasEntry := entryOn(entries, currDay)
+			if hasEntry {
+				d.Entry = entry
+				d.Entries = entriesOn(entries, currDay)
+			}
+			d.Classname = getClassname(month, currDay, hasEntry, entry.IsOwn)
+			if hasEntry && !entry.IsOwn {
+				d.Color = entry.Color
+			}
+			if hasEntry && entry.IsTentative() {
+				d.Classname += " " + ClassnameTentative
+			}
+			week.Days = append(week.Days, d)
+			currDay = currDay.AddDate(0, 0, 1)
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+func entryOn(entries []Entry, day time.Time) (Entry, bool) {
+	for _, e := range entries {
+		if !day.Before(e.Begin) && !day.After(e.End) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+func entriesOn(entries []Entry, day time.Time) []Entry {
+	found := []Entry{}
+	for _, e := range entries {
+		if !day.Before(e.Begin) && !day.After(e.End) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+func weekdayNames(firstWeekday time.Weekday) []string {
+	names := make([]string, 0, 7)
+	for i := 0; i < 7; i++ {
+		names = append(names, GermanWeekdays[time.Weekday((int(firstWeekday)+i)%7)])
+	}
+	return names
+}
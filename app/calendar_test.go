@@ -0,0 +1,126 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year, month, day int) time.Time {
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildWeeks(t *testing.T) {
+	tests := []struct {
+		name         string
+		year, month  int
+		firstWeekday time.Weekday
+		weeks        int
+		firstDay     time.Time
+		lastDay      time.Time
+		weekNumbers  []int
+	}{
+		{"february starting on sunday", 2015, 2, time.Sunday, 4, date(2015, 2, 1), date(2015, 2, 28), []int{6, 7, 8, 9}},
+		{"february starting on sunday, monday first", 2015, 2, time.Monday, 5, date(2015, 1, 26), date(2015, 3, 1), []int{5, 6, 7, 8, 9}},
+		{"31 days starting on saturday", 2015, 8, time.Sunday, 6, date(2015, 7, 26), date(2015, 9, 5), []int{31, 32, 33, 34, 35, 36}},
+		{"31 days starting on saturday, monday first", 2015, 8, time.Monday, 6, date(2015, 7, 27), date(2015, 9, 6), []int{31, 32, 33, 34, 35, 36}},
+		{"leap year february", 2024, 2, time.Monday, 5, date(2024, 1, 29), date(2024, 3, 3), []int{5, 6, 7, 8, 9}},
+		{"non leap year february", 2023, 2, time.Monday, 5, date(2023, 1, 30), date(2023, 3, 5), []int{5, 6, 7, 8, 9}},
+		{"february on monday in non leap year", 2021, 2, time.Monday, 4, date(2021, 2, 1), date(2021, 2, 28), []int{5, 6, 7, 8}},
+		{"january in iso week 53", 2021, 1, time.Monday, 5, date(2020, 12, 28), date(2021, 1, 31), []int{53, 1, 2, 3, 4}},
+		{"december into next year", 2024, 12, time.Monday, 6, date(2024, 11, 25), date(2025, 1, 5), []int{48, 49, 50, 51, 52, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weeks := buildWeeks(tt.year, tt.month, tt.firstWeekday, nil)
+			if len(weeks) != tt.weeks {
+				t.Fatalf("expected %d weeks, got %d", tt.weeks, len(weeks))
+			}
+			first := weeks[0].Days[0].Date
+			last := weeks[len(weeks)-1].Days[6].Date
+			if !first.Equal(tt.firstDay) || !last.Equal(tt.lastDay) {
+				t.Errorf("expected grid %s - %s, got %s - %s", tt.firstDay.Format(time.DateOnly), tt.lastDay.Format(time.DateOnly), first.Format(time.DateOnly), last.Format(time.DateOnly))
+			}
+			if first.Weekday() != tt.firstWeekday {
+				t.Errorf("expected grid to start on %s, got %s", tt.firstWeekday, first.Weekday())
+			}
+			for i, w := range weeks {
+				if len(w.Days) != 7 {
+					t.Errorf("week %d has %d days", i, len(w.Days))
+				}
+				if w.Number != tt.weekNumbers[i] {
+					t.Errorf("week %d: expected number %d, got %d", i, tt.weekNumbers[i], w.Number)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildWeeksEntries(t *testing.T) {
+	entries := []Entry{
+		{ID: 1, User: "anna", Begin: date(2015, 7, 30), End: date(2015, 8, 2)},
+		{ID: 2, User: "frank", Begin: date(2015, 8, 31), End: date(2015, 9, 2), IsOwn: true},
+	}
+	weeks := buildWeeks(2015, 8, time.Monday, entries)
+
+	tests := []struct {
+		date      time.Time
+		entryID   int
+		classname string
+	}{
+		{date(2015, 7, 27), 0, ClassnameWrongMonth},
+		{date(2015, 7, 30), 1, ClassenamWrongMonthEntry},
+		{date(2015, 8, 1), 1, ClassenamRightMonthEntry},
+		{date(2015, 8, 2), 1, ClassenamRightMonthEntry},
+		{date(2015, 8, 3), 0, ClassnameRightMonth},
+		{date(2015, 8, 31), 2, ClassnameRightMonthOwnEntry},
+		{date(2015, 9, 2), 2, ClassnameWrongMonthOwnEntry},
+		{date(2015, 9, 3), 0, ClassnameWrongMonth},
+	}
+	for _, tt := range tests {
+		found := false
+		for _, w := range weeks {
+			for _, d := range w.Days {
+				if !d.Date.Equal(tt.date) {
+					continue
+				}
+				found = true
+				if d.Entry.ID != tt.entryID {
+					t.Errorf("%s: expected entry %d, got %d", tt.date.Format(time.DateOnly), tt.entryID, d.Entry.ID)
+				}
+				if d.Classname != tt.classname {
+					t.Errorf("%s: expected class %s, got %s", tt.date.Format(time.DateOnly), tt.classname, d.Classname)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s not in grid", tt.date.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestWeekdayNames(t *testing.T) {
+	names := weekdayNames(time.Monday)
+	if names[0] != "Mo" || names[6] != "So" {
+		t.Errorf("unexpected monday first names: %v", names)
+	}
+	names = weekdayNames(time.Sunday)
+	if names[0] != "So" || names[6] != "Sa" {
+		t.Errorf("unexpected sunday first names: %v", names)
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	tests := map[string]time.Weekday{
+		"sunday":     time.Sunday,
+		"Monday":     time.Monday,
+		" SATURDAY ": time.Saturday,
+		"":           time.Monday,
+		"montag":     time.Monday,
+	}
+	for in, want := range tests {
+		if got := ParseWeekday(in); got != want {
+			t.Errorf("ParseWeekday(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// occupiesNight reports whether the guests of the entry sleep in the house in
+// the night following the given day. The departure day is not occupied.
+func (e Entry) occupiesNight(day time.Time) bool {
+	return !day.Before(e.Begin) && day.Before(e.End)
+}
+
+// occupancy sums up the guests of all entries staying the night after day.
+func occupancy(entries []Entry, day time.Time) int {
+	guests := 0
+	for _, e := range entries {
+		if e.occupiesNight(day) {
+			guests += e.Guests
+		}
+	}
+	return guests
+}
+
+// checkCapacity fails if adding the candidate to the existing entries exceeds
+// the capacity in any night of the candidate's stay.
+func checkCapacity(existing []Entry, candidate Entry, capacity int) error {
+	if candidate.Guests > capacity {
+		return fmt.Errorf("%d guests for %d beds: %w: %w", candidate.Guests, capacity, ErrConflict, ErrCapacity)
+	}
+	for day := candidate.Begin; day.Before(candidate.End); day = day.AddDate(0, 0, 1) {
+		free := capacity - occupancy(existing, day)
+		if candidate.Guests > free {
+			return fmt.Errorf("only %d beds free on %s: %w: %w", free, day.Format(time.DateOnly), ErrConflict, ErrCapacity)
+		}
+	}
+	return nil
+}
+
+// applyCapacity sets the number of free beds of every day in the weeks.
+func applyCapacity(weeks []Week, entries []Entry, capacity int) {
+	for _, w := range weeks {
+		for i := range w.Days {
+			w.Days[i].FreeBeds = capacity - occupancy(entries, w.Days[i].Date)
+		}
+	}
+}
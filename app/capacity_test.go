@@ -0,0 +1,50 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckCapacity(t *testing.T) {
+	existing := []Entry{
+		{ID: 1, Begin: date(2024, 7, 1), End: date(2024, 7, 8), Guests: 5},
+		{ID: 2, Begin: date(2024, 7, 5), End: date(2024, 7, 10), Guests: 4},
+	}
+	tests := []struct {
+		name      string
+		candidate Entry
+		ok        bool
+	}{
+		{"fits into remaining beds", Entry{Begin: date(2024, 7, 1), End: date(2024, 7, 5), Guests: 7}, true},
+		{"too many guests on shared nights", Entry{Begin: date(2024, 7, 4), End: date(2024, 7, 6), Guests: 4}, false},
+		{"exactly full", Entry{Begin: date(2024, 7, 5), End: date(2024, 7, 8), Guests: 3}, true},
+		{"arrives on departure day", Entry{Begin: date(2024, 7, 8), End: date(2024, 7, 12), Guests: 8}, true},
+		{"more guests than beds", Entry{Begin: date(2024, 8, 1), End: date(2024, 8, 2), Guests: 13}, false},
+	}
+	for _, tt := range tests {
+		err := checkCapacity(existing, tt.candidate, 12)
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error %s", tt.name, err)
+		}
+		if !tt.ok && (!errors.Is(err, ErrConflict) || !errors.Is(err, ErrCapacity)) {
+			t.Errorf("%s: expected capacity conflict, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestApplyCapacity(t *testing.T) {
+	entries := []Entry{
+		{Begin: date(2024, 7, 1), End: date(2024, 7, 3), Guests: 5},
+		{Begin: date(2024, 7, 2), End: date(2024, 7, 4), Guests: 2},
+	}
+	weeks := buildWeeks(2024, 7, time.Monday, entries)
+	applyCapacity(weeks, entries, 12)
+	// july 2024 starts on a monday
+	want := []int{7, 5, 10, 12}
+	for i, free := range want {
+		if got := weeks[0].Days[i].FreeBeds; got != free {
+			t.Errorf("day %d: expected %d free beds, got %d", i+1, free, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"sort"
+)
+
+// LegendItem explains the color of other members' entries in the calendar.
+// Entries without color are shown with the default class.
+type LegendItem struct {
+	Name  string
+	Color string
+}
+
+// buildLegend lists the households and users with entries in the calendar
+// which are not the viewer's own, as own entries keep their own class.
+func buildLegend(entries []Entry) []LegendItem {
+	seen := map[string]bool{}
+	legend := []LegendItem{}
+	for _, e := range entries {
+		if e.IsOwn {
+			continue
+		}
+		name := e.User
+		if e.Household != "" {
+			name = e.Household
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		legend = append(legend, LegendItem{Name: name, Color: e.Color})
+	}
+	sort.Slice(legend, func(i, j int) bool {
+		return legend[i].Name < legend[j].Name
+	})
+	return legend
+}
+
+// SetAccountColor changes the calendar color of the user's entries: the
+// household's color for members of a household, the user's own otherwise.
+// An empty color resets to the default.
+func SetAccountColor(user, color string) error {
+	u, err := LoadUser(user)
+	if err != nil {
+		return err
+	}
+	if u.Household != 0 {
+		return SetHouseholdColor(u.Household, color)
+	}
+	return SetUserColor(user, color)
+}
+
+func SetUserColor(user, color string) error {
+	if color != "" && !colorPattern.MatchString(color) {
+		return fmt.Errorf("invalid color %q: %w", color, ErrInvalid)
+	}
+	_, err := middleware.DB.Exec("update users set color = ? where name = ?", color, user)
+	if err != nil {
+		return fmt.Errorf("error setting color of %s: %w", user, err)
+	}
+	Audit(AuditUpdate, "user", 0, nil, map[string]string{"name": user, "color": color})
+	return nil
+}
+
+func SetHouseholdColor(id int, color string) error {
+	if color != "" && !colorPattern.MatchString(color) {
+		return fmt.Errorf("invalid color %q: %w", color, ErrInvalid)
+	}
+	_, err := middleware.DB.Exec("update households set color = ? where id = ?", color, id)
+	if err != nil {
+		return fmt.Errorf("error setting color of household (%d): %w", id, err)
+	}
+	Audit(AuditUpdate, "household", id, nil, map[string]string{"color": color})
+	return nil
+}
+
+// AccountColor returns the color the user's entries are shown with.
+func AccountColor(user string) (string, error) {
+	var color string
+	err := middleware.DB.QueryRow("select coalesce(nullif(h.color, ''), u.color, '') from users u left join households h on h.id = u.household_id where u.name = ?", user).Scan(&color)
+	if err != nil {
+		return "", fmt.Errorf("error fetching color of %s: %w", user, err)
+	}
+	return color, nil
+}
@@ -0,0 +1,25 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildLegend(t *testing.T) {
+	entries := []Entry{
+		{User: "zora", Color: "#ff0000"},
+		{User: "anna", HouseholdID: 1, Household: "Meier", Color: "#00ff00"},
+		{User: "ben", HouseholdID: 1, Household: "Meier", Color: "#00ff00"},
+		{User: "carl"},
+		{User: "me", IsOwn: true, Color: "#0000ff"},
+		{User: "zora", Color: "#ff0000"},
+	}
+	want := []LegendItem{
+		{Name: "Meier", Color: "#00ff00"},
+		{Name: "carl"},
+		{Name: "zora", Color: "#ff0000"},
+	}
+	if got := buildLegend(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
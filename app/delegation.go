@@ -0,0 +1,182 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"strings"
+)
+
+// Delegation allows a member to book on behalf of another user, or of
+// external guests without login if Principal is empty. Admins may always
+// book for anybody.
+type Delegation struct {
+	ID        int
+	Delegate  string
+	Principal string
+}
+
+func (d Delegation) PrincipalName() string {
+	if d.Principal == "" {
+		return "externe Gäste"
+	}
+	return d.Principal
+}
+
+func LoadDelegations() ([]Delegation, error) {
+	rows, err := middleware.DB.Query("select id, delegate, coalesce(principal, '') from delegations order by delegate, principal")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching delegations: %w", err)
+	}
+	defer rows.Close()
+	delegations := []Delegation{}
+	for rows.Next() {
+		var d Delegation
+		err = rows.Scan(&d.ID, &d.Delegate, &d.Principal)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning delegation: %w", err)
+		}
+		delegations = append(delegations, d)
+	}
+	return delegations, rows.Err()
+}
+
+func CreateDelegation(d Delegation) error {
+	if d.Delegate == "" || strings.EqualFold(d.Delegate, d.Principal) {
+		return fmt.Errorf("invalid delegation %+v: %w", d, ErrInvalid)
+	}
+	var principal any
+	if d.Principal != "" {
+		principal = d.Principal
+	}
+	_, err := middleware.DB.Exec("insert into delegations (delegate, principal) values (?,?)", d.Delegate, principal)
+	if err != nil {
+		return fmt.Errorf("error inserting delegation: %w", err)
+	}
+	Audit(AuditCreate, "delegation", 0, nil, d)
+	return nil
+}
+
+func DeleteDelegation(id int) error {
+	_, err := middleware.DB.Exec("delete from delegations where id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting delegation (%d): %w", id, err)
+	}
+	Audit(AuditDelete, "delegation", id, nil, nil)
+	return nil
+}
+
+// mayBookFor reports whether the actor may create the entry, which is booked
+// for entry.User or, with a contact, for an external guest.
+func mayBookFor(actor User, delegations []Delegation, entry Entry) bool {
+	if actor.IsAdmin() {
+		return true
+	}
+	external := entry.ContactName != ""
+	if !external && strings.EqualFold(entry.User, actor.Name) {
+		return true
+	}
+	for _, d := range delegations {
+		if !strings.EqualFold(d.Delegate, actor.Name) {
+			continue
+		}
+		if external && d.Principal == "" {
+			return true
+		}
+		if !external && strings.EqualFold(d.Principal, entry.User) {
+			return true
+		}
+	}
+	return false
+}
+
+// bookedOnBehalf reports whether the actor entered the entry for somebody
+// else and may still book for them. Deleting the delegation takes the rights
+// on the entries booked with it away.
+func bookedOnBehalf(entry Entry, actor User) bool {
+	if !entry.IsOnBehalf() || !strings.EqualFold(entry.CreatedBy, actor.Name) {
+		return false
+	}
+	delegations, err := LoadDelegations()
+	if err != nil {
+		log.Default().Printf("error checking delegations of %s: %s", actor.Name, err)
+		return false
+	}
+	return mayBookFor(actor, delegations, entry)
+}
+
+// checkOnBehalf fails with ErrForbidden if the creator of the entry may not
+// book it for its beneficiary. Entries without creator are booked by the
+// system, e.g. from rotations.
+func checkOnBehalf(entry Entry) error {
+	if entry.CreatedBy == "" || (strings.EqualFold(entry.CreatedBy, entry.User) && entry.ContactName == "") {
+		return nil
+	}
+	actor, err := LoadUser(entry.CreatedBy)
+	if err != nil {
+		return err
+	}
+	delegations, err := LoadDelegations()
+	if err != nil {
+		return err
+	}
+	if !mayBookFor(actor, delegations, entry) {
+		Audit(AuditDenied, "entry", 0, nil, entry)
+		return fmt.Errorf("user %s may not book for %s: %w", entry.CreatedBy, entry.Beneficiary(), ErrForbidden)
+	}
+	return nil
+}
+
+// BookingTargets returns the users the actor may book for besides
+// themselves and whether external guests may be booked.
+func BookingTargets(user string) ([]string, bool, error) {
+	actor, err := LoadUser(user)
+	if err != nil {
+		return nil, false, err
+	}
+	if actor.IsAdmin() {
+		names, err := LoadUserNames()
+		if err != nil {
+			return nil, false, err
+		}
+		others := []string{}
+		for _, n := range names {
+			if !strings.EqualFold(n, user) {
+				others = append(others, n)
+			}
+		}
+		return others, true, nil
+	}
+	delegations, err := LoadDelegations()
+	if err != nil {
+		return nil, false, err
+	}
+	others := []string{}
+	external := false
+	for _, d := range delegations {
+		if !strings.EqualFold(d.Delegate, user) {
+			continue
+		}
+		if d.Principal == "" {
+			external = true
+		} else {
+			others = append(others, d.Principal)
+		}
+	}
+	return others, external, nil
+}
+
+// Beneficiary is who stays in the house: the external guest if the entry has
+// a contact, its user otherwise.
+func (e Entry) Beneficiary() string {
+	if e.ContactName != "" {
+		return e.ContactName
+	}
+	return e.User
+}
+
+// IsOnBehalf reports whether somebody else than the beneficiary entered the
+// booking.
+func (e Entry) IsOnBehalf() bool {
+	return e.ContactName != "" || (e.CreatedBy != "" && !strings.EqualFold(e.CreatedBy, e.User))
+}
@@ -0,0 +1,55 @@
+package app
+
+import "testing"
+
+func TestMayBookFor(t *testing.T) {
+	delegations := []Delegation{
+		{Delegate: "anna", Principal: "oma"},
+		{Delegate: "ben", Principal: ""},
+	}
+	admin := User{Name: "frank", Role: RoleAdmin}
+	anna := User{Name: "anna"}
+	ben := User{Name: "ben"}
+	tests := []struct {
+		name  string
+		actor User
+		entry Entry
+		want  bool
+	}{
+		{"self", anna, Entry{User: "anna"}, true},
+		{"delegated user", anna, Entry{User: "Oma"}, true},
+		{"other user", anna, Entry{User: "carl"}, false},
+		{"external without delegation", anna, Entry{User: "anna", ContactName: "Herr Huber"}, false},
+		{"external with delegation", ben, Entry{User: "ben", ContactName: "Herr Huber"}, true},
+		{"user with external delegation only", ben, Entry{User: "oma"}, false},
+		{"admin", admin, Entry{User: "carl"}, true},
+		{"admin external", admin, Entry{User: "frank", ContactName: "Herr Huber"}, true},
+	}
+	for _, tt := range tests {
+		if got := mayBookFor(tt.actor, delegations, tt.entry); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestBeneficiary(t *testing.T) {
+	own := Entry{User: "anna", CreatedBy: "anna"}
+	if own.Beneficiary() != "anna" || own.IsOnBehalf() {
+		t.Errorf("unexpected own entry %+v", own)
+	}
+	generated := Entry{User: "anna"}
+	if generated.IsOnBehalf() {
+		t.Errorf("generated entry is not on behalf")
+	}
+	forOma := Entry{User: "oma", CreatedBy: "anna"}
+	if forOma.Beneficiary() != "oma" || !forOma.IsOnBehalf() {
+		t.Errorf("unexpected entry for oma %+v", forOma)
+	}
+	external := Entry{User: "anna", CreatedBy: "anna", ContactName: "Herr Huber"}
+	if external.Beneficiary() != "Herr Huber" || !external.IsOnBehalf() {
+		t.Errorf("unexpected external entry %+v", external)
+	}
+	if ownedBy(forOma, "anna", 0) || ownedBy(external, "anna", 0) || !ownedBy(forOma, "oma", 0) {
+		t.Errorf("the creator should not own entries booked for others")
+	}
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"slices"
+	"time"
+)
+
+// maxFreeWindowDays limits the range searched for free windows.
+const maxFreeWindowDays = 366
+
+// FreeWindowQuery describes the stay looked for: Nights nights between the
+// earliest arrival and the latest departure, arriving on one of Weekdays if
+// any are given.
+type FreeWindowQuery struct {
+	ResourceID int
+	Nights     int
+	Guests     int
+	Earliest   time.Time
+	Latest     time.Time
+	Weekdays   []time.Weekday
+}
+
+// FreeWindow is a free period from the first possible arrival to the last
+// possible departure. A stay of Nights nights fits for every arrival day in
+// Arrivals.
+type FreeWindow struct {
+	Begin    time.Time
+	End      time.Time
+	Nights   int
+	Arrivals []time.Time
+}
+
+// FirstDeparture is the departure of a stay arriving on Begin.
+func (w FreeWindow) FirstDeparture() time.Time {
+	return w.Begin.AddDate(0, 0, w.Nights)
+}
+
+// FindFreeWindows checks every arrival day of the query like a booking of the
+// resource and joins arrivals whose stays overlap or touch into windows.
+func FindFreeWindows(resource Resource, q FreeWindowQuery, blocks []Block, entries []Entry) []FreeWindow {
+	windows := []FreeWindow{}
+	if q.Nights < 1 {
+		return windows
+	}
+	guests := max(q.Guests, 1)
+	latest := q.Latest
+	if limit := q.Earliest.AddDate(0, 0, maxFreeWindowDays); latest.After(limit) {
+		latest = limit
+	}
+	for day := q.Earliest; !day.AddDate(0, 0, q.Nights).After(latest); day = day.AddDate(0, 0, 1) {
+		if len(q.Weekdays) > 0 && !slices.Contains(q.Weekdays, day.Weekday()) {
+			continue
+		}
+		stay := Entry{ResourceID: resource.ID, Begin: day, End: day.AddDate(0, 0, q.Nights), Guests: guests}
+		if checkOccurrence(resource, stay, blocks, entries) != nil {
+			continue
+		}
+		if n := len(windows); n > 0 && !stay.Begin.After(windows[n-1].End) {
+			windows[n-1].End = stay.End
+			windows[n-1].Arrivals = append(windows[n-1].Arrivals, day)
+			continue
+		}
+		windows = append(windows, FreeWindow{Begin: stay.Begin, End: stay.End, Nights: q.Nights, Arrivals: []time.Time{day}})
+	}
+	return windows
+}
+
+// LoadFreeWindows finds the free windows of the query's resource, taking the
+// entries and blocks of its house or rooms into account.
+func LoadFreeWindows(q FreeWindowQuery) ([]FreeWindow, error) {
+	resource, resources, err := ResolveResource(q.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	scope := conflictScope(resources, resource.ID)
+	blocks, err := loadBlocks(scope, q.Earliest, q.Latest)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := loadEntries(scope, q.Earliest, q.Latest)
+	if err != nil {
+		return nil, err
+	}
+	return FindFreeWindows(resource, q, blocks, entries), nil
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindFreeWindows(t *testing.T) {
+	house := Resource{ID: 1}
+	entries := []Entry{{ID: 1, ResourceID: 1, Begin: date(2024, 7, 6), End: date(2024, 7, 9), Guests: 2}}
+	blocks := []Block{{ID: 1, ResourceID: 1, Begin: date(2024, 7, 15), End: date(2024, 7, 16), Kind: BlockMaintenance}}
+	q := FreeWindowQuery{ResourceID: 1, Nights: 2, Earliest: date(2024, 7, 1), Latest: date(2024, 7, 20)}
+
+	windows := FindFreeWindows(house, q, blocks, entries)
+	want := [][2]time.Time{
+		{date(2024, 7, 1), date(2024, 7, 5)},
+		{date(2024, 7, 10), date(2024, 7, 14)},
+		{date(2024, 7, 17), date(2024, 7, 20)},
+	}
+	if len(windows) != len(want) {
+		t.Fatalf("unexpected windows %+v", windows)
+	}
+	for i, w := range windows {
+		if !w.Begin.Equal(want[i][0]) || !w.End.Equal(want[i][1]) {
+			t.Errorf("window %d: expected %v, got %s to %s", i, want[i], w.Begin.Format(time.DateOnly), w.End.Format(time.DateOnly))
+		}
+	}
+	if len(windows[0].Arrivals) != 3 || !windows[0].FirstDeparture().Equal(date(2024, 7, 3)) {
+		t.Errorf("unexpected first window %+v", windows[0])
+	}
+
+	// wednesdays only, the stays do not touch
+	q.Weekdays = []time.Weekday{time.Wednesday}
+	windows = FindFreeWindows(house, q, blocks, entries)
+	if len(windows) != 3 || !windows[1].Begin.Equal(date(2024, 7, 10)) || !windows[1].End.Equal(date(2024, 7, 12)) {
+		t.Errorf("unexpected wednesday windows %+v", windows)
+	}
+
+	q.Nights = 0
+	if windows := FindFreeWindows(house, q, blocks, entries); len(windows) != 0 {
+		t.Errorf("expected no windows without nights, got %+v", windows)
+	}
+}
+
+func TestFindFreeWindowsCapacity(t *testing.T) {
+	house := Resource{ID: 1, Capacity: 4}
+	entries := []Entry{{ID: 1, ResourceID: 1, Begin: date(2024, 7, 2), End: date(2024, 7, 4), Guests: 3}}
+	q := FreeWindowQuery{ResourceID: 1, Nights: 1, Guests: 2, Earliest: date(2024, 7, 1), Latest: date(2024, 7, 5)}
+
+	windows := FindFreeWindows(house, q, nil, entries)
+	if len(windows) != 2 || !windows[0].End.Equal(date(2024, 7, 2)) || !windows[1].Begin.Equal(date(2024, 7, 4)) {
+		t.Errorf("unexpected windows %+v", windows)
+	}
+	q.Guests = 1
+	if windows := FindFreeWindows(house, q, nil, entries); len(windows) != 1 {
+		t.Errorf("expected a single window for one guest, got %+v", windows)
+	}
+}
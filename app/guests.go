@@ -0,0 +1,225 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"franklyner/gores/middleware"
+	"strings"
+)
+
+// Age categories of guests, each with its own tourist tax rate.
+const (
+	CategoryAdult = "adult"
+	CategoryYouth = "youth"
+	CategoryChild = "child"
+)
+
+// GuestCategories lists the age categories in the order of reports.
+var GuestCategories = []string{CategoryAdult, CategoryYouth, CategoryChild}
+
+var GermanGuestCategories = map[string]string{
+	CategoryAdult: "Erwachsene",
+	CategoryYouth: "Jugendliche",
+	CategoryChild: "Kinder",
+}
+
+// Guest is a person registered as staying overnight with an entry. A guest
+// list is complete, it includes the member who booked the entry if present.
+type Guest struct {
+	ID          int
+	EntryID     int
+	Name        string
+	Category    string
+	Nationality string // ISO country code like CH
+}
+
+func (g Guest) CategoryName() string {
+	return GermanGuestCategories[g.Category]
+}
+
+// LoadGuests returns the guests registered with the entries by entry id.
+func LoadGuests(entryIDs ...int) (map[int][]Guest, error) {
+	guests := map[int][]Guest{}
+	if len(entryIDs) == 0 {
+		return guests, nil
+	}
+	in, args := inClause(entryIDs)
+	rows, err := middleware.DB.Query("select id, entry_id, name, category, nationality from entry_guests where entry_id in "+in+" order by id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching guests: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var g Guest
+		err = rows.Scan(&g.ID, &g.EntryID, &g.Name, &g.Category, &g.Nationality)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning guest: %w", err)
+		}
+		guests[g.EntryID] = append(guests[g.EntryID], g)
+	}
+	return guests, rows.Err()
+}
+
+// SaveGuests replaces the guest list of the entry. Rows without name are
+// dropped, whoever may change the entry may change its guests.
+func SaveGuests(entryID int, guests []Guest, user string) error {
+	entry, err := LoadEntry(entryID)
+	if err != nil {
+		return err
+	}
+	if !canModify(user, entry) {
+		Audit(AuditDenied, "entry_guests", entryID, nil, guests)
+		return fmt.Errorf("user %s may not change guests of entry %d: %w", user, entryID, ErrForbidden)
+	}
+	before, err := LoadGuests(entryID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	saved, err := replaceGuests(tx, entryID, guests)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error saving guests of entry (%d): %w", entryID, err)
+	}
+	Audit(AuditUpdate, "entry_guests", entryID, before[entryID], saved)
+	return nil
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// replaceGuests stores the guests of the entry instead of the previous ones.
+func replaceGuests(db execer, entryID int, guests []Guest) ([]Guest, error) {
+	_, err := db.Exec("delete from entry_guests where entry_id = ?", entryID)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting guests of entry (%d): %w", entryID, err)
+	}
+	saved := []Guest{}
+	for _, g := range guests {
+		g.Name = strings.TrimSpace(g.Name)
+		if g.Name == "" {
+			continue
+		}
+		if GermanGuestCategories[g.Category] == "" {
+			g.Category = CategoryAdult
+		}
+		g.Nationality = strings.ToUpper(strings.TrimSpace(g.Nationality))
+		_, err = db.Exec("insert into entry_guests (entry_id, name, category, nationality) values (?,?,?,?)", entryID, g.Name, g.Category, g.Nationality)
+		if err != nil {
+			return nil, fmt.Errorf("error inserting guest of entry (%d): %w", entryID, err)
+		}
+		g.EntryID = entryID
+		saved = append(saved, g)
+	}
+	return saved, nil
+}
+
+// mergeGuests keeps age category and nationality of guests which are named
+// again when the guest names of an entry are edited.
+func mergeGuests(existing, named []Guest) []Guest {
+	merged := make([]Guest, 0, len(named))
+	for _, g := range named {
+		for _, e := range existing {
+			if strings.EqualFold(strings.TrimSpace(e.Name), strings.TrimSpace(g.Name)) {
+				g.Category = e.Category
+				g.Nationality = e.Nationality
+				break
+			}
+		}
+		merged = append(merged, g)
+	}
+	return merged
+}
+
+// isMember tells whether the guest is the member who booked the entry.
+func (e Entry) isMember(g Guest) bool {
+	return strings.EqualFold(strings.TrimSpace(g.Name), e.User)
+}
+
+// withMember completes the guests named in the booking form, which are the
+// ones besides the member, with the member unless absent. Without named
+// guests the list stays empty and the stay is counted by its number of
+// guests. For external guests the member is the one who booked and never
+// stays.
+func withMember(entry Entry) Entry {
+	if entry.ContactName != "" {
+		entry.MemberAbsent = true
+	}
+	guests := []Guest{}
+	for _, g := range entry.GuestList {
+		if strings.TrimSpace(g.Name) != "" && !entry.isMember(g) {
+			guests = append(guests, g)
+		}
+	}
+	if len(guests) > 0 && !entry.MemberAbsent {
+		guests = append([]Guest{{Name: entry.User, Category: CategoryAdult}}, guests...)
+	}
+	entry.GuestList = guests
+	return entry
+}
+
+// withGuestDetails makes sure the number of guests and children covers the
+// named guests and the member, so that capacity checks and prices are based
+// on everybody staying in the house.
+func withGuestDetails(entry Entry) Entry {
+	known := 0
+	children := 0
+	for _, g := range entry.OtherGuests() {
+		known++
+		if g.Category == CategoryChild {
+			children++
+		}
+	}
+	if !entry.MemberAbsent {
+		known++
+	}
+	entry.Guests = max(entry.Guests, known, 1)
+	entry.Children = min(max(entry.Children, children), entry.Guests)
+	return entry
+}
+
+// attachGuests loads the named guests of the entries for display.
+func attachGuests(entries []Entry) error {
+	ids := make([]int, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	guests, err := LoadGuests(ids...)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].GuestList = guests[entries[i].ID]
+	}
+	return nil
+}
+
+// OtherGuests are the named guests besides the member.
+func (e Entry) OtherGuests() []Guest {
+	guests := []Guest{}
+	for _, g := range e.GuestList {
+		if strings.TrimSpace(g.Name) != "" && !e.isMember(g) {
+			guests = append(guests, g)
+		}
+	}
+	return guests
+}
+
+// GuestNames lists the guests besides the member one per line, as edited in
+// the booking forms.
+func (e Entry) GuestNames() string {
+	names := []string{}
+	for _, g := range e.OtherGuests() {
+		names = append(names, g.Name)
+	}
+	return strings.Join(names, "\n")
+}
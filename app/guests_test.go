@@ -0,0 +1,68 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithGuestDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    Entry
+		guests   int
+		children int
+	}{
+		{"member only", Entry{Guests: 0}, 1, 0},
+		{"count kept if higher", Entry{Guests: 4, Children: 1}, 4, 1},
+		{"member and named guests", Entry{Guests: 1, GuestList: []Guest{{Name: "Tim", Category: CategoryYouth}, {Name: "Lea", Category: CategoryChild}}}, 3, 1},
+		{"member absent", Entry{Guests: 1, MemberAbsent: true, GuestList: []Guest{{Name: "Oma"}, {Name: "Opa"}}}, 2, 0},
+		{"blank names ignored", Entry{Guests: 1, MemberAbsent: true, GuestList: []Guest{{Name: " "}}}, 1, 0},
+	}
+	for _, tt := range tests {
+		got := withGuestDetails(tt.entry)
+		if got.Guests != tt.guests || got.Children != tt.children {
+			t.Errorf("%s: expected %d guests and %d children, got %d and %d", tt.name, tt.guests, tt.children, got.Guests, got.Children)
+		}
+	}
+}
+
+func TestWithMember(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry Entry
+		want  []string
+	}{
+		{"no named guests", Entry{User: "anna"}, []string{}},
+		{"member added", Entry{User: "anna", GuestList: []Guest{{Name: "Tim"}}}, []string{"anna", "Tim"}},
+		{"member named again", Entry{User: "anna", GuestList: []Guest{{Name: "Anna "}, {Name: "Tim"}}}, []string{"anna", "Tim"}},
+		{"member absent", Entry{User: "anna", MemberAbsent: true, GuestList: []Guest{{Name: "anna"}, {Name: "Tim"}, {Name: " "}}}, []string{"Tim"}},
+		{"external guests", Entry{User: "admin", ContactName: "Familie Meier", GuestList: []Guest{{Name: "Tim"}}}, []string{"Tim"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, g := range withMember(tt.entry).GuestList {
+			got = append(got, g.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMergeGuests(t *testing.T) {
+	existing := []Guest{
+		{ID: 1, Name: "Tim", Category: CategoryYouth, Nationality: "DE"},
+		{ID: 2, Name: "Lea", Category: CategoryChild, Nationality: "CH"},
+	}
+	got := mergeGuests(existing, []Guest{{Name: "tim"}, {Name: "Max"}})
+	want := []Guest{
+		{Name: "tim", Category: CategoryYouth, Nationality: "DE"},
+		{Name: "Max"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if names := (Entry{GuestList: existing}).GuestNames(); names != "Tim\nLea" {
+		t.Errorf("unexpected names %q", names)
+	}
+}
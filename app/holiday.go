@@ -0,0 +1,296 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"franklyner/gores/middleware"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	HolidayPublic = "public"
+	HolidaySchool = "school"
+
+	ClassnameHoliday       = "holiday"
+	ClassnameSchoolHoliday = "schoolholiday"
+)
+
+var GermanHolidayKinds = map[string]string{
+	HolidayPublic: "Feiertag",
+	HolidaySchool: "Schulferien",
+}
+
+// Holiday is a public holiday or a school holiday period, from the bundled
+// data files or imported from ICS.
+type Holiday struct {
+	ID     int
+	Name   string
+	Begin  time.Time
+	End    time.Time // last day, inclusive
+	Kind   string
+	Source string // region of bundled files, name of imports
+}
+
+func (h Holiday) KindName() string {
+	return GermanHolidayKinds[h.Kind]
+}
+
+func (h Holiday) contains(day time.Time) bool {
+	return !day.Before(h.Begin) && !day.After(h.End)
+}
+
+// BundledHolidays are loaded from the data files of the configured regions
+// at startup.
+var BundledHolidays []Holiday
+
+// LoadHolidayFiles reads the files <region>.ics from dir, e.g. ch.ics for the
+// swiss public holidays and ch-zh.ics for the ones of the canton of Zurich.
+// Files named <region>-school.ics contain school holidays, e.g.
+// ch-zh-school.ics the ones of the city of Zurich.
+func LoadHolidayFiles(dir string, regions []string) ([]Holiday, error) {
+	holidays := []Holiday{}
+	for _, region := range regions {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			continue
+		}
+		kind := HolidayPublic
+		if strings.HasSuffix(region, "-school") {
+			kind = HolidaySchool
+		}
+		f, err := os.Open(filepath.Join(dir, region+".ics"))
+		if err != nil {
+			return nil, fmt.Errorf("error opening holidays of %s: %w", region, err)
+		}
+		parsed, err := ParseICS(f, kind, region)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, parsed...)
+	}
+	return holidays, nil
+}
+
+// ParseICS reads the all-day events of an iCalendar file. Events with a time
+// are taken as lasting the whole day.
+func ParseICS(r io.Reader, kind, source string) ([]Holiday, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	holidays := []Holiday{}
+	var current *Holiday
+	var end time.Time
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Holiday{Kind: kind, Source: source}
+			end = time.Time{}
+		case current == nil:
+		case name == "SUMMARY":
+			current.Name = unescapeICS(value)
+		case name == "DTSTART":
+			current.Begin, err = parseICSDate(value)
+		case name == "DTEND":
+			end, err = parseICSDate(value)
+			// the end of all-day events, given as a date without time, is
+			// exclusive
+			if err == nil && len(value) == 8 {
+				end = end.AddDate(0, 0, -1)
+			}
+		case name == "END" && value == "VEVENT":
+			if current.Begin.IsZero() {
+				return nil, fmt.Errorf("event without start in %s: %q", source, current.Name)
+			}
+			current.End = current.Begin
+			if end.After(current.Begin) {
+				current.End = end
+			}
+			holidays = append(holidays, *current)
+			current = nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in %s: %w", name, source, err)
+		}
+	}
+	return holidays, nil
+}
+
+// unfoldICS joins continuation lines, which start with a space or tab.
+func unfoldICS(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return time.Parse("20060102", value[:8])
+}
+
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(s)
+}
+
+// LoadHolidays returns the bundled and imported holidays overlapping
+// from..to.
+func LoadHolidays(from, to time.Time) ([]Holiday, error) {
+	holidays := []Holiday{}
+	for _, h := range BundledHolidays {
+		if !h.End.Before(from) && !h.Begin.After(to) {
+			holidays = append(holidays, h)
+		}
+	}
+	imported, err := queryHolidays("where end >= ? and begin <= ?", from, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(holidays, imported...), nil
+}
+
+// LoadImportedHolidays returns all holidays imported from ICS.
+func LoadImportedHolidays() ([]Holiday, error) {
+	return queryHolidays("")
+}
+
+// HolidaysOfKind returns all bundled and imported holidays of the kind, for
+// the policy's peak periods.
+func HolidaysOfKind(kind string) ([]Holiday, error) {
+	holidays := []Holiday{}
+	for _, h := range BundledHolidays {
+		if h.Kind == kind {
+			holidays = append(holidays, h)
+		}
+	}
+	imported, err := queryHolidays("where kind = ?", kind)
+	if err != nil {
+		return nil, err
+	}
+	return append(holidays, imported...), nil
+}
+
+func queryHolidays(where string, args ...any) ([]Holiday, error) {
+	rows, err := middleware.DB.Query("select id, name, begin, end, kind, source from holidays "+where+" order by begin", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching holidays: %w", err)
+	}
+	defer rows.Close()
+	holidays := []Holiday{}
+	for rows.Next() {
+		var h Holiday
+		err = rows.Scan(&h.ID, &h.Name, &h.Begin, &h.End, &h.Kind, &h.Source)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, rows.Err()
+}
+
+// ImportHolidays stores the events of the ICS data under the source name,
+// replacing an earlier import of the same source. It returns the number of
+// imported holidays.
+func ImportHolidays(r io.Reader, kind, source string) (int, error) {
+	if GermanHolidayKinds[kind] == "" || source == "" {
+		return 0, fmt.Errorf("invalid holiday import %q (%s): %w", source, kind, ErrInvalid)
+	}
+	holidays, err := ParseICS(r, kind, source)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("delete from holidays where source = ?", source)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting holidays of %s: %w", source, err)
+	}
+	for _, h := range holidays {
+		_, err = tx.Exec("insert into holidays (name, begin, end, kind, source) values (?,?,?,?,?)", h.Name, h.Begin, h.End, h.Kind, h.Source)
+		if err != nil {
+			return 0, fmt.Errorf("error inserting holiday %q: %w", h.Name, err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("error importing holidays of %s: %w", source, err)
+	}
+	Audit(AuditCreate, "holidays", 0, nil, map[string]any{"source": source, "kind": kind, "count": len(holidays)})
+	return len(holidays), nil
+}
+
+func DeleteHolidaySource(source string) error {
+	_, err := middleware.DB.Exec("delete from holidays where source = ?", source)
+	if err != nil {
+		return fmt.Errorf("error deleting holidays of %s: %w", source, err)
+	}
+	Audit(AuditDelete, "holidays", 0, map[string]string{"source": source}, nil)
+	return nil
+}
+
+// applyHolidays marks the days of the weeks falling on holidays. Blocks keep
+// their class, the marker is added to it.
+func applyHolidays(weeks []Week, holidays []Holiday) {
+	for _, w := range weeks {
+		for i := range w.Days {
+			day := &w.Days[i]
+			for _, h := range holidays {
+				if !h.contains(day.Date) {
+					continue
+				}
+				day.Holidays = append(day.Holidays, h)
+				class := ClassnameHoliday
+				if h.Kind == HolidaySchool {
+					class = ClassnameSchoolHoliday
+				}
+				if !slices.Contains(strings.Fields(day.Classname), class) {
+					day.Classname += " " + class
+				}
+			}
+		}
+	}
+}
+
+// HolidayNames lists the holidays of the day for tooltips.
+func (d Day) HolidayNames() string {
+	names := make([]string, 0, len(d.Holidays))
+	for _, h := range d.Holidays {
+		names = append(names, h.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// loadCalendarHolidays loads the holidays for a calendar view. Failures only
+// hide the markers.
+func loadCalendarHolidays(from, to time.Time) []Holiday {
+	holidays, err := LoadHolidays(from, to)
+	if err != nil {
+		log.Default().Printf("error loading holidays: %s", err)
+		return BundledHolidays
+	}
+	return holidays
+}
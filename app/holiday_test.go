@@ -0,0 +1,115 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20250712\r\n" +
+	"DTEND;VALUE=DATE:20250818\r\n" +
+	"SUMMARY:Sommerferien\\, Kanton\r\n" +
+	"  Zürich\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20251224T080000Z\r\n" +
+	"SUMMARY:Heiligabend\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE-TIME:20251227T090000\r\n" +
+	"DTEND;VALUE=DATE-TIME:20251228T170000\r\n" +
+	"SUMMARY:Skiweekend\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	holidays, err := ParseICS(strings.NewReader(testICS), HolidaySchool, "zh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(holidays) != 3 {
+		t.Fatalf("expected 3 holidays, got %+v", holidays)
+	}
+	summer := holidays[0]
+	if summer.Name != "Sommerferien, Kanton Zürich" || !summer.Begin.Equal(date(2025, 7, 12)) || !summer.End.Equal(date(2025, 8, 17)) || summer.Kind != HolidaySchool {
+		t.Errorf("unexpected summer holidays %+v", summer)
+	}
+	eve := holidays[1]
+	if !eve.Begin.Equal(date(2025, 12, 24)) || !eve.End.Equal(eve.Begin) {
+		t.Errorf("unexpected christmas eve %+v", eve)
+	}
+	// the end of timed events is the day they end on
+	ski := holidays[2]
+	if !ski.Begin.Equal(date(2025, 12, 27)) || !ski.End.Equal(date(2025, 12, 28)) {
+		t.Errorf("unexpected ski weekend %+v", ski)
+	}
+
+	_, err = ParseICS(strings.NewReader("BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n"), HolidayPublic, "x")
+	if err == nil {
+		t.Errorf("expected error for event without start")
+	}
+}
+
+func TestBundledHolidayFiles(t *testing.T) {
+	holidays, err := LoadHolidayFiles("../holidays", []string{"ch", "ch-zh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, h := range holidays {
+		if h.Kind != HolidayPublic || !h.End.Equal(h.Begin) {
+			t.Errorf("unexpected holiday %+v", h)
+		}
+		found[h.Begin.Format("2006-01-02")+" "+h.Name] = true
+	}
+	for _, want := range []string{"2026-04-03 Karfreitag", "2026-05-14 Auffahrt", "2027-03-29 Ostermontag", "2026-01-02 Berchtoldstag"} {
+		if !found[want] {
+			t.Errorf("missing %s", want)
+		}
+	}
+	school, err := LoadHolidayFiles("../holidays", []string{"ch-zh-school"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(school) == 0 {
+		t.Fatal("missing school holidays")
+	}
+	for _, h := range school {
+		if h.Kind != HolidaySchool || h.Begin.Weekday() != time.Saturday || h.End.Weekday() != time.Sunday {
+			t.Errorf("unexpected school holidays %+v", h)
+		}
+	}
+	if _, err := LoadHolidayFiles("../holidays", []string{"xx"}); err == nil {
+		t.Errorf("expected error for unknown region")
+	}
+}
+
+func TestApplyHolidays(t *testing.T) {
+	weeks := buildWeeks(2025, 8, 1, nil)
+	holidays := []Holiday{
+		{Name: "Bundesfeier", Begin: date(2025, 8, 1), End: date(2025, 8, 1), Kind: HolidayPublic},
+		{Name: "Sommerferien", Begin: date(2025, 7, 12), End: date(2025, 8, 17), Kind: HolidaySchool},
+	}
+	applyHolidays(weeks, holidays)
+	for _, w := range weeks {
+		for _, d := range w.Days {
+			switch {
+			case d.Date.Equal(date(2025, 8, 1)):
+				if d.Classname != ClassnameRightMonth+" "+ClassnameHoliday+" "+ClassnameSchoolHoliday || d.HolidayNames() != "Bundesfeier, Sommerferien" {
+					t.Errorf("unexpected 1.8. %q %q", d.Classname, d.HolidayNames())
+				}
+			case d.Date.Equal(date(2025, 8, 18)):
+				if len(d.Holidays) != 0 {
+					t.Errorf("unexpected holidays on 18.8. %+v", d.Holidays)
+				}
+			}
+		}
+	}
+
+	p := Policy{PeakHolidays: holidays[1:]}
+	if !p.isPeak(date(2025, 7, 20)) || p.isPeak(date(2025, 8, 18)) {
+		t.Errorf("school holidays should be peak")
+	}
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"regexp"
+	"strconv"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Household groups the users of one family. Bookings of any member count
+// against the household's quotas and are shown as own to all members.
+type Household struct {
+	ID      int
+	Name    string
+	Color   string // background of the household's entries in the calendar
+	Members []string
+}
+
+func LoadHouseholds() ([]Household, error) {
+	rows, err := middleware.DB.Query("select id, name, color from households order by name")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching households: %w", err)
+	}
+	defer rows.Close()
+
+	households := []Household{}
+	index := map[int]int{}
+	for rows.Next() {
+		var h Household
+		err = rows.Scan(&h.ID, &h.Name, &h.Color)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning household: %w", err)
+		}
+		index[h.ID] = len(households)
+		households = append(households, h)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching households: %w", rows.Err())
+	}
+
+	members, err := middleware.DB.Query("select name, household_id from users where household_id is not null order by name")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching household members: %w", err)
+	}
+	defer members.Close()
+	for members.Next() {
+		var name string
+		var id int
+		err = members.Scan(&name, &id)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning household member: %w", err)
+		}
+		if i, ok := index[id]; ok {
+			households[i].Members = append(households[i].Members, name)
+		}
+	}
+	return households, members.Err()
+}
+
+func CreateHousehold(name, color string) error {
+	if name == "" {
+		return fmt.Errorf("household without name: %w", ErrInvalid)
+	}
+	if !colorPattern.MatchString(color) {
+		color = ""
+	}
+	_, err := middleware.DB.Exec("insert into households (name, color) values (?,?)", name, color)
+	if err != nil {
+		return fmt.Errorf("error inserting household (%s): %w", name, err)
+	}
+	Audit(AuditCreate, "household", 0, nil, Household{Name: name, Color: color})
+	return nil
+}
+
+// SetHousehold moves the user into the household, 0 removes the user from
+// any household. Entries the user booked without household join it, so they
+// count against its quotas and statements. Entries booked for a household
+// stay with it.
+func SetHousehold(user string, householdID int) error {
+	var id any
+	if householdID != 0 {
+		id = householdID
+	}
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("update users set household_id = ? where name = ?", id, user)
+	if err != nil {
+		return fmt.Errorf("error setting household of %s: %w", user, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no user found (%s): %w", user, ErrNotFound)
+	}
+	if householdID != 0 {
+		_, err = tx.Exec("update entries set household_id = ? where user = ? and household_id is null", householdID, user)
+		if err != nil {
+			return fmt.Errorf("error moving entries of %s into household (%d): %w", user, householdID, err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error setting household of %s: %w", user, err)
+	}
+	Audit(AuditUpdate, "user", 0, nil, map[string]any{"user": user, "household": householdID})
+	return nil
+}
+
+// LoadUserNames returns the names of all users, e.g. for assigning them to
+// households.
+func LoadUserNames() ([]string, error) {
+	rows, err := middleware.DB.Query("select name from users order by name")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching users: %w", err)
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// ownedBy reports whether the entry belongs to the user or the user's
+// household. Bookings for external guests belong to nobody, see
+// bookedOnBehalf for the rights of their creator.
+func ownedBy(entry Entry, user string, householdID int) bool {
+	if entry.ContactName != "" {
+		return false
+	}
+	if entry.User == user {
+		return true
+	}
+	return householdID != 0 && entry.HouseholdID == householdID
+}
+
+// isOwn checks ownership for the logged in user, including the entries they
+// may still manage for others.
+func isOwn(entry Entry) bool {
+	householdID, _ := strconv.Atoi(middleware.Session.Get("household"))
+	actor := User{Name: middleware.Session.Get("username"), Role: middleware.Session.Get("role"), Household: householdID}
+	return ownedBy(entry, actor.Name, actor.Household) || bookedOnBehalf(entry, actor)
+}
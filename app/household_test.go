@@ -0,0 +1,51 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOwnedBy(t *testing.T) {
+	tests := []struct {
+		entry     Entry
+		user      string
+		household int
+		want      bool
+	}{
+		{Entry{User: "anna"}, "anna", 0, true},
+		{Entry{User: "anna"}, "peter", 0, false},
+		{Entry{User: "anna", HouseholdID: 2}, "peter", 2, true},
+		{Entry{User: "anna", HouseholdID: 2}, "peter", 3, false},
+		{Entry{User: "anna", HouseholdID: 2}, "peter", 0, false},
+		{Entry{User: "anna", CreatedBy: "peter"}, "peter", 0, false},
+		{Entry{User: "anna", ContactName: "Herr Huber"}, "anna", 0, false},
+	}
+	for i, tt := range tests {
+		if got := ownedBy(tt.entry, tt.user, tt.household); got != tt.want {
+			t.Errorf("case %d: expected %t", i, tt.want)
+		}
+	}
+}
+
+func TestBuildWeeksHouseholdColor(t *testing.T) {
+	entries := []Entry{
+		{User: "anna", Begin: date(2024, 3, 4), End: date(2024, 3, 5), Color: "#ff8800"},
+		{User: "peter", Begin: date(2024, 3, 11), End: date(2024, 3, 12), Color: "#0088ff", IsOwn: true},
+	}
+	weeks := buildWeeks(2024, 3, time.Monday, entries)
+	colors := map[time.Time]string{}
+	for _, w := range weeks {
+		for _, d := range w.Days {
+			colors[d.Date] = d.Color
+		}
+	}
+	if c := colors[date(2024, 3, 4)]; c != "#ff8800" {
+		t.Errorf("expected household color, got %q", c)
+	}
+	if c := colors[date(2024, 3, 11)]; c != "" {
+		t.Errorf("expected own entries without household color, got %q", c)
+	}
+	if c := colors[date(2024, 3, 6)]; c != "" {
+		t.Errorf("expected free days without color, got %q", c)
+	}
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"franklyner/gores/middleware"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaxRates is the tourist tax (Kurtaxe) per guest and night by age category.
+var TaxRates = map[string]Money{}
+
+// ParseTaxRates reads rates in the form "adult:2.50,youth:1.20,child:0".
+func ParseTaxRates(s string) (map[string]Money, error) {
+	rates := map[string]Money{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		category, amount, ok := strings.Cut(part, ":")
+		if !ok || GermanGuestCategories[category] == "" {
+			return nil, fmt.Errorf("invalid tax rate (%s), expected category:amount", part)
+		}
+		rate, err := ParseMoney(amount)
+		if err != nil {
+			return nil, err
+		}
+		rates[category] = rate
+	}
+	return rates, nil
+}
+
+// TaxLine is one guest of a stay within the period of a tax report.
+type TaxLine struct {
+	Entry  Entry
+	Guest  Guest // without name if the guests of the entry are not registered
+	Nights int   // nights within the period
+	Tax    Money
+}
+
+// TaxTotal sums up the guest-nights of one age category.
+type TaxTotal struct {
+	Category    string
+	GuestNights int
+	Rate        Money
+	Tax         Money
+}
+
+func (t TaxTotal) CategoryName() string {
+	return GermanGuestCategories[t.Category]
+}
+
+// NationalityTotal sums up the guest-nights by nationality, as required by
+// the statistics of the municipality.
+type NationalityTotal struct {
+	Nationality string
+	GuestNights int
+}
+
+// TaxReport lists the guest-nights of confirmed stays in a period and the
+// tourist tax owed for them.
+type TaxReport struct {
+	From          time.Time
+	To            time.Time
+	Lines         []TaxLine
+	Totals        []TaxTotal
+	Nationalities []NationalityTotal
+	Total         Money
+	Unregistered  int // stays without guest list, counted by their number of guests
+}
+
+// buildTaxReport counts the nights from..to (inclusive) of the entries.
+// Stays without registered guests are counted as their number of adults and
+// children.
+func buildTaxReport(from, to time.Time, entries []Entry, guests map[int][]Guest, rates map[string]Money) TaxReport {
+	r := TaxReport{From: from, To: to, Lines: []TaxLine{}}
+	inPeriod := func(day time.Time) bool { return !day.Before(from) && !day.After(to) }
+	guestNights := map[string]int{}
+	nationalities := map[string]int{}
+	for _, e := range entries {
+		if e.Status != StatusConfirmed {
+			continue
+		}
+		nights := nightsWhere(e, inPeriod)
+		if nights == 0 {
+			continue
+		}
+		list := guests[e.ID]
+		if len(list) == 0 {
+			r.Unregistered++
+		}
+		// guests booked but not named are taxed by the entry's numbers
+		children := e.Children
+		for _, g := range list {
+			if g.Category != CategoryAdult {
+				children--
+			}
+		}
+		for i := e.Guests - len(list); i > 0; i-- {
+			category := CategoryAdult
+			if i <= children {
+				category = CategoryChild
+			}
+			list = append(list, Guest{EntryID: e.ID, Category: category})
+		}
+		for _, g := range list {
+			tax := Money(nights) * rates[g.Category]
+			r.Lines = append(r.Lines, TaxLine{Entry: e, Guest: g, Nights: nights, Tax: tax})
+			guestNights[g.Category] += nights
+			nationalities[g.Nationality] += nights
+			r.Total += tax
+		}
+	}
+	for _, category := range GuestCategories {
+		r.Totals = append(r.Totals, TaxTotal{
+			Category:    category,
+			GuestNights: guestNights[category],
+			Rate:        rates[category],
+			Tax:         Money(guestNights[category]) * rates[category],
+		})
+	}
+	for nationality, n := range nationalities {
+		r.Nationalities = append(r.Nationalities, NationalityTotal{Nationality: nationality, GuestNights: n})
+	}
+	sort.Slice(r.Nationalities, func(i, j int) bool {
+		return r.Nationalities[i].Nationality < r.Nationalities[j].Nationality
+	})
+	return r
+}
+
+// LoadTaxReport builds the tourist tax report of the days from..to.
+func LoadTaxReport(from, to time.Time) (TaxReport, error) {
+	where, args := EntryFilter{From: from, To: to, Statuses: []string{StatusConfirmed}}.where()
+	rows, err := middleware.DB.Query("select "+entryColumns+" from "+entryTables+" where "+where+" order by e.begin", args...)
+	if err != nil {
+		return TaxReport{}, fmt.Errorf("error fetching entries for tax report: %w", err)
+	}
+	defer rows.Close()
+	entries := []Entry{}
+	ids := []int{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return TaxReport{}, err
+		}
+		entries = append(entries, entry)
+		ids = append(ids, entry.ID)
+	}
+	if rows.Err() != nil {
+		return TaxReport{}, fmt.Errorf("error fetching entries for tax report: %w", rows.Err())
+	}
+	entries, err = expandEntries(entries, from, to)
+	if err != nil {
+		return TaxReport{}, err
+	}
+	guests, err := LoadGuests(ids...)
+	if err != nil {
+		return TaxReport{}, err
+	}
+	return buildTaxReport(from, to, entries, guests, TaxRates), nil
+}
+
+// WriteCSV exports one line per guest, separated by semicolons as expected
+// by spreadsheets with german settings.
+func (r TaxReport) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Comma = ';'
+	out.Write([]string{"Anreise", "Abreise", "Objekt", "Mitglied", "Name", "Kategorie", "Nationalität", "Nächte", "Kurtaxe"})
+	for _, l := range r.Lines {
+		out.Write([]string{
+			l.Entry.Begin.Format("02.01.2006"),
+			l.Entry.End.Format("02.01.2006"),
+			l.Entry.ResourceName,
+			l.Entry.User,
+			l.Guest.Name,
+			l.Guest.CategoryName(),
+			l.Guest.Nationality,
+			strconv.Itoa(l.Nights),
+			l.Tax.String(),
+		})
+	}
+	out.Write([]string{"", "", "", "", "Total", "", "", "", r.Total.String()})
+	out.Flush()
+	return out.Error()
+}
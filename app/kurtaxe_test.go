@@ -0,0 +1,69 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseTaxRates(t *testing.T) {
+	rates, err := ParseTaxRates("adult:2.50, youth:1.2,child:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates[CategoryAdult] != 250 || rates[CategoryYouth] != 120 || rates[CategoryChild] != 0 {
+		t.Errorf("unexpected rates %v", rates)
+	}
+	for _, in := range []string{"senior:1", "adult", "adult:x"} {
+		if _, err := ParseTaxRates(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+func TestBuildTaxReport(t *testing.T) {
+	rates := map[string]Money{CategoryAdult: 250, CategoryYouth: 120}
+	entries := []Entry{
+		// 2 of 5 nights in may
+		{ID: 1, User: "anna", Begin: date(2024, 4, 28), End: date(2024, 5, 3), Guests: 2, Status: StatusConfirmed},
+		// not registered: 2 adults, 1 child for 3 nights
+		{ID: 2, User: "ben", Begin: date(2024, 5, 10), End: date(2024, 5, 13), Guests: 3, Children: 1, Status: StatusConfirmed},
+		{ID: 3, User: "carl", Begin: date(2024, 5, 20), End: date(2024, 5, 22), Guests: 1, Status: StatusRequested},
+		{ID: 4, User: "dora", Begin: date(2024, 6, 1), End: date(2024, 6, 3), Guests: 1, Status: StatusConfirmed},
+		// the member registered with a named child in the booking form
+		{ID: 5, User: "eva", Begin: date(2024, 5, 25), End: date(2024, 5, 26), Guests: 2, Status: StatusConfirmed},
+		// only one of 4 guests named: the others are 1 adult and 2 children
+		{ID: 6, User: "fred", Begin: date(2024, 5, 27), End: date(2024, 5, 28), Guests: 4, Children: 2, Status: StatusConfirmed},
+	}
+	guests := map[int][]Guest{
+		1: {{Name: "Anna", Category: CategoryAdult, Nationality: "CH"}, {Name: "Tim", Category: CategoryYouth, Nationality: "DE"}},
+		5: withMember(Entry{User: "eva", GuestList: []Guest{{Name: "Leo", Category: CategoryChild}}}).GuestList,
+		6: {{Name: "Fred", Category: CategoryAdult}},
+	}
+	r := buildTaxReport(date(2024, 5, 1), date(2024, 5, 31), entries, guests, rates)
+
+	if len(r.Lines) != 11 || r.Unregistered != 1 {
+		t.Fatalf("unexpected lines %+v", r.Lines)
+	}
+	want := map[string]int{CategoryAdult: 2 + 6 + 1 + 2, CategoryYouth: 2, CategoryChild: 3 + 1 + 2}
+	for _, total := range r.Totals {
+		if total.GuestNights != want[total.Category] {
+			t.Errorf("%s: expected %d guest-nights, got %d", total.Category, want[total.Category], total.GuestNights)
+		}
+	}
+	if r.Total != 11*250+2*120 {
+		t.Errorf("unexpected total %s", r.Total)
+	}
+	if len(r.Nationalities) != 3 || r.Nationalities[0].Nationality != "" || r.Nationalities[0].GuestNights != 15 {
+		t.Errorf("unexpected nationalities %+v", r.Nationalities)
+	}
+
+	var out bytes.Buffer
+	if err := r.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 13 || lines[1] != "28.04.2024;03.05.2024;;anna;Anna;Erwachsene;CH;2;5.00" {
+		t.Errorf("unexpected csv %q", out.String())
+	}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"strings"
+	"time"
+)
+
+const DefaultPageSize = 20
+
+type EntryFilter struct {
+	User      string    // only entries of this user if set
+	Household int       // only entries of this household if set
+	From      time.Time // entries ending on or after this day
+	To        time.Time // entries beginning on or before this day, unlimited if zero
+	Text      string    // every word has to appear in user or bemerkungen
+	Resource  int       // only entries of this resource if set
+	Statuses  []string  // only active entries if empty
+	Deleted   bool      // entries in the trash instead of the others
+	Page      int       // 1-based
+	PageSize  int
+}
+
+type EntryPage struct {
+	Entries   []Entry
+	Total     int
+	Page      int
+	PageCount int
+	PrevPage  int
+	NextPage  int
+}
+
+func ListEntries(filter EntryFilter) (EntryPage, error) {
+	return queryEntries(filter, "e.begin asc")
+}
+
+func queryEntries(filter EntryFilter, order string) (EntryPage, error) {
+	if filter.PageSize <= 0 {
+		filter.PageSize = DefaultPageSize
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	where, args := filter.where()
+	var total int
+	err := middleware.DB.QueryRow("select count(*) from "+entryTables+" where "+where, args...).Scan(&total)
+	if err != nil {
+		return EntryPage{}, fmt.Errorf("error counting entries: %w", err)
+	}
+
+	args = append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	entries, err := selectEntries(where+" order by "+order+" limit ? offset ?", args...)
+	if err != nil {
+		return EntryPage{}, err
+	}
+	return newEntryPage(entries, total, filter.Page, filter.PageSize), nil
+}
+
+// queryAllEntries returns every entry matching the filter, ignoring its
+// pagination.
+func queryAllEntries(filter EntryFilter, order string) ([]Entry, error) {
+	where, args := filter.where()
+	return selectEntries(where+" order by "+order, args...)
+}
+
+func selectEntries(cond string, args ...any) ([]Entry, error) {
+	rows, err := middleware.DB.Query("select "+entryColumns+" from "+entryTables+" where "+cond, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entry.IsOwn = isOwn(entry)
+		entry.Month = int(entry.Begin.Month())
+		entry.Year = entry.Begin.Year()
+		entries = append(entries, entry)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching entries: %w", rows.Err())
+	}
+	err = attachGuests(entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (f EntryFilter) where() (string, []any) {
+	conds := []string{notDeletedCond}
+	if f.Deleted {
+		conds[0] = deletedCond
+	}
+	args := []any{}
+	if f.User != "" {
+		conds = append(conds, "e.user = ?")
+		args = append(args, f.User)
+	}
+	if f.Household != 0 {
+		conds = append(conds, "e.household_id = ?")
+		args = append(args, f.Household)
+	}
+	for _, term := range searchTerms(f.Text) {
+		conds = append(conds, "(e.user like ? or e.bemerkungen like ?)")
+		pattern := "%" + escapeLike(term) + "%"
+		args = append(args, pattern, pattern)
+	}
+	if len(f.Statuses) == 0 {
+		conds = append(conds, activeStatusCond)
+	} else {
+		conds = append(conds, "e.status in ("+strings.TrimSuffix(strings.Repeat("?,", len(f.Statuses)), ",")+")")
+		for _, status := range f.Statuses {
+			args = append(args, status)
+		}
+	}
+	if f.Resource != 0 {
+		conds = append(conds, "e.resource_id = ?")
+		args = append(args, f.Resource)
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, "(e.end >= ? or e.rrule <> '')")
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "e.begin <= ?")
+		args = append(args, f.To)
+	}
+	return strings.Join(conds, " and "), args
+}
+
+func newEntryPage(entries []Entry, total, page, pageSize int) EntryPage {
+	pageCount := (total + pageSize - 1) / pageSize
+	p := EntryPage{
+		Entries:   entries,
+		Total:     total,
+		Page:      page,
+		PageCount: pageCount,
+	}
+	if page > 1 {
+		p.PrevPage = page - 1
+	}
+	if page < pageCount {
+		p.NextPage = page + 1
+	}
+	return p
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewEntryPage(t *testing.T) {
+	tests := []struct {
+		total, page           int
+		pageCount, prev, next int
+	}{
+		{0, 1, 0, 0, 0},
+		{20, 1, 1, 0, 0},
+		{21, 1, 2, 0, 2},
+		{45, 2, 3, 1, 3},
+		{45, 3, 3, 2, 0},
+	}
+	for _, tt := range tests {
+		p := newEntryPage(nil, tt.total, tt.page, 20)
+		if p.PageCount != tt.pageCount || p.PrevPage != tt.prev || p.NextPage != tt.next {
+			t.Errorf("total %d page %d: got count %d prev %d next %d", tt.total, tt.page, p.PageCount, p.PrevPage, p.NextPage)
+		}
+	}
+}
+
+func TestEntryFilterWhereTrash(t *testing.T) {
+	where, _ := EntryFilter{}.where()
+	if !strings.HasPrefix(where, notDeletedCond+" and ") {
+		t.Errorf("expected deleted entries to be hidden, got %q", where)
+	}
+	where, _ = EntryFilter{Deleted: true, User: "anna"}.where()
+	if !strings.HasPrefix(where, deletedCond+" and e.user = ?") {
+		t.Errorf("expected only deleted entries, got %q", where)
+	}
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyBonus is added to the weight of a participant for every earlier
+// round in which they did not get any of their wishes.
+const historyBonus = 0.5
+
+type LotteryRound struct {
+	Year         int
+	RequestOpen  time.Time
+	RequestClose time.Time
+	Weighted     bool // use the history of earlier rounds as weights
+	Seed         int64
+	Drawn        bool
+}
+
+// IsOpen reports whether wishes may be submitted.
+func (r LotteryRound) IsOpen(now time.Time) bool {
+	return !r.Drawn && !now.Before(r.RequestOpen) && now.Before(r.RequestClose.AddDate(0, 0, 1))
+}
+
+type LotteryPeriod struct {
+	ID         int
+	Year       int
+	ResourceID int
+	Label      string
+	Begin      time.Time
+	End        time.Time
+}
+
+type Wish struct {
+	User     string
+	PeriodID int
+	Rank     int // 1 is the most wanted period
+}
+
+// checkWishes verifies that ranks maps periods of the round to positive and
+// distinct ranks.
+func checkWishes(periods []LotteryPeriod, ranks map[int]int) error {
+	known := make(map[int]bool, len(periods))
+	for _, p := range periods {
+		known[p.ID] = true
+	}
+	used := map[int]bool{}
+	for periodID, rank := range ranks {
+		if !known[periodID] {
+			return fmt.Errorf("period %d is not part of the lottery: %w", periodID, ErrInvalid)
+		}
+		if rank < 1 || used[rank] {
+			return fmt.Errorf("invalid rank %d for period %d: %w", rank, periodID, ErrInvalid)
+		}
+		used[rank] = true
+	}
+	return nil
+}
+
+// LotteryOutcome explains the draw for one participant.
+type LotteryOutcome struct {
+	User        string
+	Weight      float64
+	Position    int   // 1-based position in the drawn order
+	Won         []int // ids of the allocated periods
+	Explanation []string
+}
+
+// DrawLottery allocates the periods to the users wishing for them. Users are
+// put in a random order, weighted by weights (1 if missing), derived from seed
+// so a draw can be reproduced. In every pass each user in that order gets their
+// best ranked period which is still free; passes are repeated until no more
+// wishes can be fulfilled, so nobody gets a second period before everybody
+// had the chance to get a first one.
+func DrawLottery(periods []LotteryPeriod, wishes []Wish, weights map[string]float64, seed int64) []LotteryOutcome {
+	labels := make(map[int]string, len(periods))
+	for _, p := range periods {
+		labels[p.ID] = p.Label
+	}
+	byUser := map[string][]Wish{}
+	for _, w := range wishes {
+		if _, ok := labels[w.PeriodID]; ok {
+			byUser[w.User] = append(byUser[w.User], w)
+		}
+	}
+	users := make([]string, 0, len(byUser))
+	for user, ws := range byUser {
+		sort.Slice(ws, func(i, j int) bool { return ws[i].Rank < ws[j].Rank })
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	// weighted random order (Efraimidis-Spirakis): sort by u^(1/weight)
+	rnd := rand.New(rand.NewSource(seed))
+	keys := make(map[string]float64, len(users))
+	outcomes := make(map[string]*LotteryOutcome, len(users))
+	for _, user := range users {
+		weight := weights[user]
+		if weight <= 0 {
+			weight = 1
+		}
+		keys[user] = math.Pow(rnd.Float64(), 1/weight)
+		outcomes[user] = &LotteryOutcome{User: user, Weight: weight}
+	}
+	sort.SliceStable(users, func(i, j int) bool { return keys[users[i]] > keys[users[j]] })
+	for i, user := range users {
+		o := outcomes[user]
+		o.Position = i + 1
+		o.Explanation = append(o.Explanation, fmt.Sprintf("Platz %d von %d in der Losreihenfolge (Gewicht %.1f).", o.Position, len(users), o.Weight))
+	}
+
+	allocated := map[int]string{}
+	for pass := 1; ; pass++ {
+		progress := false
+		for _, user := range users {
+			o := outcomes[user]
+			for _, w := range byUser[user] {
+				if owner, taken := allocated[w.PeriodID]; taken {
+					if pass == 1 && owner != user {
+						o.Explanation = append(o.Explanation, fmt.Sprintf("Wunsch %d (%s) ging an %s.", w.Rank, labels[w.PeriodID], owner))
+					}
+					continue
+				}
+				allocated[w.PeriodID] = user
+				o.Won = append(o.Won, w.PeriodID)
+				o.Explanation = append(o.Explanation, fmt.Sprintf("Wunsch %d (%s) zugeteilt in Runde %d.", w.Rank, labels[w.PeriodID], pass))
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+
+	result := make([]LotteryOutcome, 0, len(users))
+	for _, user := range users {
+		o := outcomes[user]
+		if len(o.Won) == 0 {
+			o.Explanation = append(o.Explanation, "Leider konnte keiner deiner Wünsche erfüllt werden, das erhöht dein Gewicht in der nächsten gewichteten Verlosung.")
+		}
+		result = append(result, *o)
+	}
+	return result
+}
+
+// LotteryResult is the stored outcome of a participant.
+type LotteryResult struct {
+	Year        int
+	User        string
+	Weight      float64
+	Position    int
+	Won         int
+	Explanation string
+}
+
+// historyWeights derives the weights of a weighted draw from the results of
+// earlier rounds.
+func historyWeights(history []LotteryResult) map[string]float64 {
+	weights := map[string]float64{}
+	for _, r := range history {
+		if _, ok := weights[r.User]; !ok {
+			weights[r.User] = 1
+		}
+		if r.Won == 0 {
+			weights[r.User] += historyBonus
+		}
+	}
+	return weights
+}
+
+func (o LotteryOutcome) ExplanationText() string {
+	return strings.Join(o.Explanation, " ")
+}
@@ -0,0 +1,288 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"time"
+)
+
+func LoadLotteryRound(year int) (LotteryRound, error) {
+	r := LotteryRound{Year: year}
+	var seed sql.NullInt64
+	var drawnAt sql.NullTime
+	err := middleware.DB.QueryRow("select request_open, request_close, weighted, seed, drawn_at from lottery_rounds where year = ?", year).Scan(&r.RequestOpen, &r.RequestClose, &r.Weighted, &seed, &drawnAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return LotteryRound{}, fmt.Errorf("no lottery for %d: %w", year, ErrNotFound)
+	}
+	if err != nil {
+		return LotteryRound{}, fmt.Errorf("error fetching lottery round (%d): %w", year, err)
+	}
+	r.Seed = seed.Int64
+	r.Drawn = drawnAt.Valid
+	return r, nil
+}
+
+func SaveLotteryRound(r LotteryRound) error {
+	_, err := middleware.DB.Exec("insert into lottery_rounds (year, request_open, request_close, weighted) values (?,?,?,?) on duplicate key update request_open = ?, request_close = ?, weighted = ?", r.Year, r.RequestOpen, r.RequestClose, r.Weighted, r.RequestOpen, r.RequestClose, r.Weighted)
+	if err != nil {
+		return fmt.Errorf("error saving lottery round (%d): %w", r.Year, err)
+	}
+	Audit(AuditUpdate, "lottery_round", r.Year, nil, r)
+	return nil
+}
+
+func LoadLotteryPeriods(year int) ([]LotteryPeriod, error) {
+	rows, err := middleware.DB.Query("select id, year, resource_id, label, begin, end from lottery_periods where year = ? order by begin", year)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lottery periods: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []LotteryPeriod{}
+	for rows.Next() {
+		var p LotteryPeriod
+		err = rows.Scan(&p.ID, &p.Year, &p.ResourceID, &p.Label, &p.Begin, &p.End)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning lottery period: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching lottery periods: %w", rows.Err())
+	}
+	return periods, nil
+}
+
+func AddLotteryPeriod(p LotteryPeriod) error {
+	_, err := middleware.DB.Exec("insert into lottery_periods (year, resource_id, label, begin, end) values (?,?,?,?,?)", p.Year, p.ResourceID, p.Label, p.Begin, p.End)
+	if err != nil {
+		return fmt.Errorf("error inserting lottery period: %w", err)
+	}
+	Audit(AuditCreate, "lottery_period", 0, nil, p)
+	return nil
+}
+
+// SaveWishes replaces the wishes of the user. ranks maps period ids of the
+// year to distinct ranks from 1, periods without rank are not wished for.
+func SaveWishes(year int, user string, ranks map[int]int) error {
+	round, err := LoadLotteryRound(year)
+	if err != nil {
+		return err
+	}
+	if !round.IsOpen(time.Now()) {
+		return fmt.Errorf("lottery %d does not accept wishes: %w", year, ErrForbidden)
+	}
+	periods, err := LoadLotteryPeriods(year)
+	if err != nil {
+		return err
+	}
+	err = checkWishes(periods, ranks)
+	if err != nil {
+		return err
+	}
+
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("delete from lottery_wishes where year = ? and user = ?", year, user)
+	if err != nil {
+		return fmt.Errorf("error deleting wishes: %w", err)
+	}
+	for periodID, rank := range ranks {
+		_, err = tx.Exec("insert into lottery_wishes (year, user, period_id, rank_no) values (?,?,?,?)", year, user, periodID, rank)
+		if err != nil {
+			return fmt.Errorf("error inserting wish: %w", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error saving wishes: %w", err)
+	}
+	return nil
+}
+
+// LoadWishes returns the wishes of the year, only the ones of user if set.
+func LoadWishes(year int, user string) ([]Wish, error) {
+	query := "select user, period_id, rank_no from lottery_wishes where year = ?"
+	args := []any{year}
+	if user != "" {
+		query += " and user = ?"
+		args = append(args, user)
+	}
+	rows, err := middleware.DB.Query(query+" order by user, rank_no", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching wishes: %w", err)
+	}
+	defer rows.Close()
+
+	wishes := []Wish{}
+	for rows.Next() {
+		var w Wish
+		err = rows.Scan(&w.User, &w.PeriodID, &w.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning wish: %w", err)
+		}
+		wishes = append(wishes, w)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching wishes: %w", rows.Err())
+	}
+	return wishes, nil
+}
+
+// LoadLotteryResults returns the results of the year, only the one of user if
+// set.
+func LoadLotteryResults(year int, user string) ([]LotteryResult, error) {
+	query := "select year, user, weight, position, won, explanation from lottery_results where year = ?"
+	args := []any{year}
+	if user != "" {
+		query += " and user = ?"
+		args = append(args, user)
+	}
+	return queryLotteryResults(query+" order by position", args...)
+}
+
+func loadLotteryHistory(before int) ([]LotteryResult, error) {
+	return queryLotteryResults("select year, user, weight, position, won, explanation from lottery_results where year < ? order by year, position", before)
+}
+
+func queryLotteryResults(query string, args ...any) ([]LotteryResult, error) {
+	rows, err := middleware.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching lottery results: %w", err)
+	}
+	defer rows.Close()
+
+	results := []LotteryResult{}
+	for rows.Next() {
+		var r LotteryResult
+		err = rows.Scan(&r.Year, &r.User, &r.Weight, &r.Position, &r.Won, &r.Explanation)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning lottery result: %w", err)
+		}
+		results = append(results, r)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching lottery results: %w", rows.Err())
+	}
+	return results, nil
+}
+
+// RunLottery draws the lottery of the year with the given seed, stores the
+// results, creates confirmed entries for the allocated periods and notifies
+// all participants.
+func RunLottery(year int, seed int64) ([]LotteryOutcome, error) {
+	round, err := LoadLotteryRound(year)
+	if err != nil {
+		return nil, err
+	}
+	periods, err := LoadLotteryPeriods(year)
+	if err != nil {
+		return nil, err
+	}
+	wishes, err := LoadWishes(year, "")
+	if err != nil {
+		return nil, err
+	}
+	weights := map[string]float64{}
+	if round.Weighted {
+		history, err := loadLotteryHistory(year)
+		if err != nil {
+			return nil, err
+		}
+		weights = historyWeights(history)
+	}
+
+	// the round is marked as drawn together with its entries and results, so
+	// it is either drawn completely or can be drawn again
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("update lottery_rounds set seed = ?, drawn_at = ? where year = ? and drawn_at is null", seed, time.Now(), year)
+	if err != nil {
+		return nil, fmt.Errorf("error marking lottery %d as drawn: %w", year, err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return nil, fmt.Errorf("lottery %d was already drawn: %w", year, ErrConflict)
+	}
+
+	outcomes := DrawLottery(periods, wishes, weights, seed)
+	byID := make(map[int]LotteryPeriod, len(periods))
+	for _, p := range periods {
+		byID[p.ID] = p
+	}
+	created := []Entry{}
+	for i, o := range outcomes {
+		// only allocated periods count for the history of later draws
+		won := 0
+		for _, id := range o.Won {
+			entry, explanation, err := allocatePeriod(tx, o.User, byID[id], created)
+			if err != nil {
+				return nil, err
+			}
+			if explanation != "" {
+				outcomes[i].Explanation = append(outcomes[i].Explanation, explanation)
+				continue
+			}
+			won++
+			created = append(created, entry)
+		}
+		_, err = tx.Exec("insert into lottery_results (year, user, weight, position, won, explanation) values (?,?,?,?,?,?)", year, o.User, o.Weight, o.Position, won, outcomes[i].ExplanationText())
+		if err != nil {
+			return nil, fmt.Errorf("error storing lottery result of %s: %w", o.User, err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error storing lottery %d: %w", year, err)
+	}
+
+	Audit(AuditLotteryDrawn, "lottery_round", year, nil, map[string]int64{"seed": seed})
+	for _, e := range created {
+		Audit(AuditCreate, "entry", e.ID, nil, e)
+	}
+	for _, o := range outcomes {
+		Notify(o.User, fmt.Sprintf("Die Verlosung %d ist erfolgt: %s", year, o.ExplanationText()))
+	}
+	return outcomes, nil
+}
+
+// allocatePeriod creates the entry of a won period within tx. If the period
+// is not available it returns an explanation instead, errors abort the draw.
+// created are the entries stored in tx so far, which are not visible to the
+// availability check otherwise.
+func allocatePeriod(tx execer, user string, p LotteryPeriod, created []Entry) (Entry, string, error) {
+	entry := Entry{
+		User:        user,
+		ResourceID:  p.ResourceID,
+		Begin:       p.Begin,
+		End:         p.End,
+		Guests:      1,
+		Status:      StatusConfirmed,
+		Bemerkungen: "Verlosung " + p.Label,
+	}
+	resource, resources, err := ResolveResource(p.ResourceID)
+	if err != nil {
+		return Entry{}, "", err
+	}
+	err = checkAvailability(resources, resource, entry, created...)
+	if errors.Is(err, ErrConflict) {
+		log.Default().Printf("lottery period %d of %s is not available: %s", p.ID, user, err)
+		return Entry{}, fmt.Sprintf("%s konnte nicht eingetragen werden, bitte melde dich beim Admin.", p.Label), nil
+	}
+	if err != nil {
+		return Entry{}, "", err
+	}
+	entry, err = storeEntry(tx, entry)
+	if err != nil {
+		return Entry{}, "", fmt.Errorf("error creating entry for lottery period %d of %s: %w", p.ID, user, err)
+	}
+	return entry, "", nil
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCheckWishes(t *testing.T) {
+	periods := []LotteryPeriod{{ID: 1}, {ID: 2}, {ID: 3}}
+	tests := []struct {
+		ranks map[int]int
+		valid bool
+	}{
+		{map[int]int{}, true},
+		{map[int]int{1: 2, 3: 1}, true},
+		{map[int]int{4: 1}, false},
+		{map[int]int{1: 0}, false},
+		{map[int]int{1: -1}, false},
+		{map[int]int{1: 1, 2: 1}, false},
+	}
+	for i, tt := range tests {
+		err := checkWishes(periods, tt.ranks)
+		if tt.valid && err != nil || !tt.valid && !errors.Is(err, ErrInvalid) {
+			t.Errorf("case %d: unexpected %v", i, err)
+		}
+	}
+}
+
+func TestDrawLottery(t *testing.T) {
+	periods := []LotteryPeriod{
+		{ID: 1, Label: "Weihnachten"},
+		{ID: 2, Label: "Neujahr"},
+		{ID: 3, Label: "Ostern"},
+	}
+	wishes := []Wish{
+		{User: "anna", PeriodID: 1, Rank: 1},
+		{User: "anna", PeriodID: 2, Rank: 2},
+		{User: "beat", PeriodID: 1, Rank: 1},
+		{User: "beat", PeriodID: 3, Rank: 2},
+		{User: "carla", PeriodID: 1, Rank: 1},
+		{User: "carla", PeriodID: 9, Rank: 2}, // unknown period is ignored
+	}
+
+	outcomes := DrawLottery(periods, wishes, nil, 42)
+	if !reflect.DeepEqual(outcomes, DrawLottery(periods, wishes, nil, 42)) {
+		t.Fatal("expected the same seed to produce the same draw")
+	}
+	if len(outcomes) != 3 {
+		t.Fatalf("expected 3 participants, got %d", len(outcomes))
+	}
+
+	owners := map[int]string{}
+	for i, o := range outcomes {
+		if o.Position != i+1 {
+			t.Errorf("expected outcomes in drawn order, got position %d at %d", o.Position, i)
+		}
+		for _, id := range o.Won {
+			if other, ok := owners[id]; ok {
+				t.Errorf("period %d allocated to %s and %s", id, other, o.User)
+			}
+			owners[id] = o.User
+		}
+		if len(o.Explanation) == 0 {
+			t.Errorf("expected explanation for %s", o.User)
+		}
+	}
+	// the first drawn participant gets their first wish
+	if owners[1] != outcomes[0].User {
+		t.Errorf("expected Weihnachten for %s, got %s", outcomes[0].User, owners[1])
+	}
+	// every period somebody wished for is allocated
+	if len(owners) != 3 {
+		t.Errorf("expected all periods to be allocated, got %v", owners)
+	}
+}
+
+func TestDrawLotteryRounds(t *testing.T) {
+	periods := []LotteryPeriod{{ID: 1, Label: "A"}, {ID: 2, Label: "B"}, {ID: 3, Label: "C"}}
+	wishes := []Wish{
+		{User: "anna", PeriodID: 1, Rank: 1},
+		{User: "anna", PeriodID: 2, Rank: 2},
+		{User: "anna", PeriodID: 3, Rank: 3},
+		{User: "beat", PeriodID: 1, Rank: 1},
+		{User: "beat", PeriodID: 2, Rank: 2},
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		outcomes := DrawLottery(periods, wishes, nil, seed)
+		won := map[string]int{}
+		for _, o := range outcomes {
+			won[o.User] = len(o.Won)
+		}
+		// nobody gets a second period before everybody got a first one
+		if won["beat"] != 1 || won["anna"] != 2 {
+			t.Errorf("seed %d: unexpected allocation %v", seed, won)
+		}
+	}
+}
+
+func TestDrawLotteryWeights(t *testing.T) {
+	periods := []LotteryPeriod{{ID: 1, Label: "A"}}
+	wishes := []Wish{
+		{User: "anna", PeriodID: 1, Rank: 1},
+		{User: "beat", PeriodID: 1, Rank: 1},
+	}
+	weights := map[string]float64{"beat": 20}
+	wins := 0
+	for seed := int64(0); seed < 100; seed++ {
+		outcomes := DrawLottery(periods, wishes, weights, seed)
+		if outcomes[0].User == "beat" {
+			wins++
+		}
+	}
+	if wins < 80 {
+		t.Errorf("expected heavily weighted participant to win most draws, won %d of 100", wins)
+	}
+}
+
+func TestHistoryWeights(t *testing.T) {
+	weights := historyWeights([]LotteryResult{
+		{Year: 2022, User: "anna", Won: 0},
+		{Year: 2023, User: "anna", Won: 0},
+		{Year: 2023, User: "beat", Won: 1},
+	})
+	if weights["anna"] != 2 || weights["beat"] != 1 {
+		t.Errorf("unexpected weights %v", weights)
+	}
+}
@@ -1,15 +1,20 @@
 package app
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"franklyner/gores/middleware"
 	"log"
+	"slices"
 	"strings"
 	"time"
 )
 
 const (
+	RoleAdmin = "admin"
+
+	ClassnameTentative          = "tentative"
 	ClassnameRightMonth         = "rightmonth"
 	ClassnameWrongMonth         = "wrongmonth"
 	ClassenamRightMonthEntry    = "res_rightmonth"
@@ -22,6 +27,9 @@ var (
 	ErrorMultipleUsers = errors.New("multiple users found")
 	ErrNotFound        = errors.New("record not found")
 	ErrConflict        = errors.New("conflict")
+	ErrForbidden       = errors.New("forbidden")
+	ErrCapacity        = errors.New("not enough beds")
+	ErrInvalid         = errors.New("invalid input")
 
 	GermanMonths = map[int]string{
 		1:  "Januar",
@@ -40,28 +48,91 @@ var (
 )
 
 type User struct {
-	Name     string
-	Email    string
-	Phone    string
-	Password string
+	Name      string
+	Email     string
+	Phone     string
+	Password  string
+	Role      string
+	Household int // id of the user's household, 0 if none
+}
+
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
 
 type Entry struct {
-	ID          int
-	User        string
-	Begin       time.Time
-	End         time.Time
-	Bemerkungen string
-	IsOwn       bool
-	Month       int
-	Year        int
+	ID           int
+	ResourceID   int
+	ResourceName string
+	User         string
+	CreatedBy    string // who entered the entry, empty for generated entries
+	ContactName  string // external guest the entry was booked for by User
+	ContactInfo  string // phone or email of the external guest
+	HouseholdID  int
+	Household    string
+	Color        string // color of the household, or of the user without household
+	Begin        time.Time
+	End          time.Time
+	Bemerkungen  string
+	Guests       int
+	Children     int     // part of Guests
+	MemberAbsent bool    // the member only booked for the guests
+	GuestList    []Guest // registered guests, the member included if present
+	Cost         Money   // of the (first) stay, computed when the entry is saved
+	Status       string
+	Recurrence   string    // RRULE, empty for single entries
+	DeletedAt    time.Time // zero unless the entry is in the trash
+	DeletedBy    string
+	IsOwn        bool
+	Month        int
+	Year         int
+}
+
+const (
+	entryColumns = "e.res_id, e.user, e.begin, e.end, e.bemerkungen, e.resource_id, coalesce(r.name, ''), e.guests, e.status, coalesce(e.household_id, 0), coalesce(h.name, ''), coalesce(nullif(h.color, ''), u.color, ''), e.rrule, e.deleted_at, coalesce(e.deleted_by, ''), e.children, e.cost, e.member_absent, coalesce(e.created_by, ''), e.contact_name, e.contact_info"
+	entryTables  = "entries e left join resources r on r.id = e.resource_id left join households h on h.id = e.household_id left join users u on u.name = e.user"
+)
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEntry(rows rowScanner) (Entry, error) {
+	var entry Entry
+	var deletedAt sql.NullTime
+	err := rows.Scan(&entry.ID, &entry.User, &entry.Begin, &entry.End, &entry.Bemerkungen, &entry.ResourceID, &entry.ResourceName, &entry.Guests, &entry.Status, &entry.HouseholdID, &entry.Household, &entry.Color, &entry.Recurrence, &deletedAt, &entry.DeletedBy, &entry.Children, &entry.Cost, &entry.MemberAbsent, &entry.CreatedBy, &entry.ContactName, &entry.ContactInfo)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error scanning row: %w", err)
+	}
+	entry.DeletedAt = deletedAt.Time
+	return entry, nil
+}
+
+// IsActive reports whether the entry occupies the house, i.e. it is neither
+// declined nor cancelled.
+func (e Entry) IsActive() bool {
+	return e.Status == StatusRequested || e.Status == StatusConfirmed
+}
+
+func (e Entry) IsTentative() bool {
+	return e.Status == StatusRequested
+}
+
+func (e Entry) StatusName() string {
+	return GermanStatus[e.Status]
 }
 
 type Day struct {
+	Date       time.Time
 	DayOfMonth int
 	Month      int
 	Entry      Entry
+	Entries    []Entry // all entries on this day, more than one in capacity mode
+	FreeBeds   int
 	Classname  string
+	Color      string // household color of other households' entries
+	Block      Block  // ID is 0 if the day is not blocked
+	Holidays   []Holiday
 }
 
 type Calendar struct {
@@ -78,76 +149,325 @@ type Calendar struct {
 	DecYear        int
 	IncMonth       int
 	IncYear        int
-	Weeks          [][]Day
+	Resource       Resource
+	Resources      []Resource
+	Weeks          []Week
+	WeekdayNames   []string
 	Message        string
 	AllDaysInMonth []int
 	AllMonths      []int
 	AllYears       []int
 	AllEntries     []Entry
+	AllBlocks      []Block
+	Legend         []LegendItem
+}
+
+// CreateEntry stores a new entry and returns it with its id and status.
+func CreateEntry(entry Entry) (Entry, error) {
+	err := checkOnBehalf(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	resource, resources, err := ResolveResource(entry.ResourceID)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.ResourceID = resource.ID
+	entry = withGuestDetails(withMember(entry))
+	entry, err = attributeHousehold(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	err = checkPolicy(entry, nil)
+	if err != nil {
+		return Entry{}, err
+	}
+	err = checkAvailability(resources, resource, entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Status, err = initialStatus(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	return insertEntry(entry)
+}
+
+// attributeHousehold books the entry for the household of its user unless a
+// household is set already. Names without user account are booked without
+// household.
+func attributeHousehold(entry Entry) (Entry, error) {
+	if entry.HouseholdID != 0 {
+		return entry, nil
+	}
+	user, err := LoadUser(entry.User)
+	if errors.Is(err, ErrNotFound) {
+		return entry, nil
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.HouseholdID = user.Household
+	return entry, nil
 }
 
-func CreateEntry(entry Entry) error {
-	query := `
-		SELECT * FROM entries
-		WHERE (
-			BEGIN <= ?
-			AND END >= ?
-		)
-		OR (
-			BEGIN <= ?
-			AND END >= ?
-		)
-		OR (
-			BEGIN >= ?
-			AND END <= ?
-		)`
-	rows, err := middleware.DB.Query(query, entry.Begin, entry.Begin, entry.End, entry.End, entry.Begin, entry.End)
+func insertEntry(entry Entry) (Entry, error) {
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return Entry{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	entry, err = storeEntry(tx, entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return Entry{}, fmt.Errorf("error committing new entry: %w", err)
+	}
+	Audit(AuditCreate, "entry", entry.ID, nil, entry)
+	return entry, nil
+}
+
+// storeEntry inserts the entry and its guests with db, which may be a
+// transaction. Auditing is left to the caller.
+func storeEntry(db execer, entry Entry) (Entry, error) {
+	entry, err := attributeHousehold(entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	var household, createdBy any
+	if entry.HouseholdID != 0 {
+		household = entry.HouseholdID
+	}
+	if entry.CreatedBy != "" {
+		createdBy = entry.CreatedBy
+	}
+	entry.Cost = Prices.Cost(entry)
+	res, err := db.Exec("insert into entries (user, begin, end, bemerkungen, resource_id, guests, status, household_id, rrule, children, cost, member_absent, created_by, contact_name, contact_info) values (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+		entry.User, entry.Begin, entry.End, entry.Bemerkungen, entry.ResourceID, entry.Guests, entry.Status, household, entry.Recurrence, entry.Children, entry.Cost, entry.MemberAbsent, createdBy, entry.ContactName, entry.ContactInfo)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error inserting entry into db: %w", err)
+	}
+	id, err := res.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("error querying for conflicts: %w", err)
+		return Entry{}, fmt.Errorf("error reading id of new entry: %w", err)
 	}
-	if rows.Next() {
-		return ErrConflict
+	entry.ID = int(id)
+	if len(entry.GuestList) > 0 {
+		entry.GuestList, err = replaceGuests(db, entry.ID, entry.GuestList)
+		if err != nil {
+			return Entry{}, err
+		}
 	}
+	return entry, nil
+}
 
-	_, err = middleware.DB.Exec("insert into entries (user, begin, end, bemerkungen) values (?,?,?,?)", entry.User, entry.Begin, entry.End, entry.Bemerkungen)
+// UpdateEntry changes dates, guests and remarks of an existing active entry
+// and recalculates its cost. Named guests keep their registration details.
+// Only the owner of the entry, members of its household or an admin may
+// change it.
+func UpdateEntry(entry Entry, user string) (Entry, error) {
+	existing, err := LoadEntry(entry.ID)
+	if err != nil {
+		return Entry{}, err
+	}
+	if !canModify(user, existing) || !existing.IsActive() {
+		Audit(AuditDenied, "entry", entry.ID, existing, entry)
+		return Entry{}, fmt.Errorf("user %s may not change entry %d: %w", user, entry.ID, ErrForbidden)
+	}
+	entry.User = existing.User
+	entry.CreatedBy = existing.CreatedBy
+	entry.ContactName = existing.ContactName
+	entry.ContactInfo = existing.ContactInfo
+	entry.HouseholdID = existing.HouseholdID
+	entry.Recurrence = existing.Recurrence
+	entry.ResourceID = existing.ResourceID
+	entry = withMember(entry)
+	entry.GuestList = mergeGuests(existing.GuestList, entry.GuestList)
+	entry = withGuestDetails(entry)
+	err = checkPolicy(entry, &existing)
+	if err != nil {
+		return Entry{}, err
+	}
+	resource, resources, err := ResolveResource(existing.ResourceID)
 	if err != nil {
-		return fmt.Errorf("error inserting entry into db: %w", err)
+		return Entry{}, err
+	}
+	err = checkAvailability(resources, resource, entry)
+	if err != nil {
+		return Entry{}, err
 	}
 
+	entry.Cost = Prices.Cost(entry)
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return Entry{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("update entries set begin = ?, end = ?, bemerkungen = ?, guests = ?, children = ?, cost = ?, member_absent = ? where res_id = ?", entry.Begin, entry.End, entry.Bemerkungen, entry.Guests, entry.Children, entry.Cost, entry.MemberAbsent, entry.ID)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error updating entry (%d): %w", entry.ID, err)
+	}
+	entry.GuestList, err = replaceGuests(tx, entry.ID, entry.GuestList)
+	if err != nil {
+		return Entry{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return Entry{}, fmt.Errorf("error committing entry (%d): %w", entry.ID, err)
+	}
+	Audit(AuditUpdate, "entry", entry.ID, existing, entry)
+	if entry.Begin.After(existing.Begin) || entry.End.Before(existing.End) || entry.Guests < existing.Guests {
+		processWaitlist(existing.ResourceID, existing.Begin, existing.End)
+	}
+	return entry, nil
+}
+
+// checkAvailability returns an error wrapping ErrConflict if the entry can not
+// be booked on the resource, for recurring entries in any occurrence.
+// pending are entries stored in a transaction which is not committed yet.
+func checkAvailability(resources []Resource, resource Resource, entry Entry, pending ...Entry) error {
+	occurrences, err := entryOccurrences(entry)
+	if err != nil {
+		return err
+	}
+	if len(occurrences) == 0 {
+		return nil
+	}
+	scope := conflictScope(resources, resource.ID)
+	from, to := occurrences[0].Begin, occurrences[len(occurrences)-1].End
+	blocks, err := loadBlocks(scope, from, to)
+	if err != nil {
+		return err
+	}
+	overlapping, err := loadEntries(scope, from, to)
+	if err != nil {
+		return err
+	}
+	others := make([]Entry, 0, len(overlapping)+len(pending))
+	for _, e := range overlapping {
+		if e.ID != entry.ID {
+			others = append(others, e)
+		}
+	}
+	for _, e := range pending {
+		if slices.Contains(scope, e.ResourceID) {
+			others = append(others, e)
+		}
+	}
+	for _, occ := range occurrences {
+		err = checkOccurrence(resource, occ, blocks, others)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func DeleteEntry(id int, user string) error {
-	rows, err := middleware.DB.Query("select user from entries where res_id=?", id)
+// checkOccurrence checks a single stay against the blocks and other entries.
+// Blocked resources are never available. Resources without capacity are
+// booked exclusively, otherwise the guests of all entries must fit into the
+// beds.
+func checkOccurrence(resource Resource, entry Entry, blocks []Block, others []Entry) error {
+	for _, b := range blocks {
+		if b.overlaps(entry.Begin, entry.End) {
+			return fmt.Errorf("%s from %s to %s: %w: %w", b.Kind, b.Begin.Format(time.DateOnly), b.End.Format(time.DateOnly), ErrConflict, ErrBlocked)
+		}
+	}
+	if resource.Capacity > 0 {
+		return checkCapacity(others, entry, resource.Capacity)
+	}
+	for _, e := range others {
+		if !e.Begin.After(entry.End) && !e.End.Before(entry.Begin) {
+			return fmt.Errorf("entry %d on %s: %w", e.ID, e.Begin.Format(time.DateOnly), ErrConflict)
+		}
+	}
+	return nil
+}
+
+// LoadEntry loads an entry which is not in the trash, with its registered
+// guests.
+func LoadEntry(id int) (Entry, error) {
+	entry, err := loadEntry(id, notDeletedCond)
+	if err != nil {
+		return Entry{}, err
+	}
+	entries := []Entry{entry}
+	err = attachGuests(entries)
+	return entries[0], err
+}
+
+func loadEntry(id int, cond string) (Entry, error) {
+	rows, err := middleware.DB.Query("select "+entryColumns+" from "+entryTables+" where e.res_id = ? and "+cond, id)
 	if err != nil {
-		return fmt.Errorf("error checking for entry for delete")
+		return Entry{}, fmt.Errorf("error fetching entry (%d): %w", id, err)
 	}
-	var eu string
+	defer rows.Close()
 	if !rows.Next() {
+		if rows.Err() != nil {
+			return Entry{}, fmt.Errorf("error fetching entry: %w", rows.Err())
+		}
+		return Entry{}, fmt.Errorf("no entry found (%d): %w", id, ErrNotFound)
+	}
+	entry, err := scanEntry(rows)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.IsOwn = isOwn(entry)
+	return entry, nil
+}
+
+// canModify reports whether user may change the entry: their own, their
+// household's, the ones they booked for others as long as they may still
+// book for them or, for admins, everybody's.
+func canModify(user string, entry Entry) bool {
+	if entry.ContactName == "" && strings.EqualFold(user, entry.User) {
+		return true
+	}
+	actor, err := LoadUser(user)
+	if err != nil {
+		log.Default().Printf("error checking permissions of %s: %s", user, err)
+		return false
+	}
+	return actor.IsAdmin() || ownedBy(entry, actor.Name, actor.Household) || bookedOnBehalf(entry, actor)
+}
+
+// DeleteEntry moves the entry to the trash, from where it can be restored
+// until it is purged.
+func DeleteEntry(id int, user string) error {
+	entry, err := LoadEntry(id)
+	if errors.Is(err, ErrNotFound) {
 		log.Default().Printf("entry with id %d was not found", id)
 		return nil
 	}
-	err = rows.Scan(&eu)
 	if err != nil {
-		return fmt.Errorf("error scanning user of entry to delete: %w", err)
+		return fmt.Errorf("error checking for entry for delete: %w", err)
 	}
-	if strings.EqualFold(user, eu) || strings.EqualFold("frank", eu) {
-		_, err := middleware.DB.Exec("delete from entries where res_id = ?", id)
-		if err != nil {
-			return fmt.Errorf("error deleteing entry (%d): %w", id, err)
-		}
+	if !canModify(user, entry) {
+		Audit(AuditDenied, "entry", id, entry, nil)
+		return fmt.Errorf("user %s may not delete entry %d: %w", user, id, ErrForbidden)
+	}
+	_, err = middleware.DB.Exec("update entries set deleted_at = ?, deleted_by = ? where res_id = ?", time.Now(), user, id)
+	if err != nil {
+		return fmt.Errorf("error deleteing entry (%d): %w", id, err)
+	}
+	Audit(AuditDelete, "entry", id, entry, nil)
+	if entry.IsActive() {
+		processWaitlist(entry.ResourceID, entry.Begin, entry.End)
 	}
 	return nil
 }
 
 func LoadUser(username string) (User, error) {
-	rows, err := middleware.DB.Query("SELECT name, email, phone, pwd FROM users WHERE name=?", username)
+	rows, err := middleware.DB.Query("SELECT name, email, phone, pwd, role, coalesce(household_id, 0) FROM users WHERE name=?", username)
 	if err != nil {
 		return User{}, fmt.Errorf("error fetching user (%s): %w", username, err)
 	}
 	defer rows.Close()
 
-	var name, email, phone, password string
+	var name, email, phone, password, role string
+	var household int
 
 	if !rows.Next() {
 		if rows.Err() != nil {
@@ -155,64 +475,55 @@ func LoadUser(username string) (User, error) {
 		}
 		return User{}, fmt.Errorf("no user found (%s): %w", username, ErrNotFound)
 	}
-	err = rows.Scan(&name, &email, &phone, &password)
+	err = rows.Scan(&name, &email, &phone, &password, &role, &household)
 	if err != nil {
 		return User{}, fmt.Errorf("error fetching rows from db: %w", err)
 	}
 
 	return User{
-		Name:     name,
-		Email:    email,
-		Phone:    phone,
-		Password: password,
+		Name:      name,
+		Email:     email,
+		Phone:     phone,
+		Password:  password,
+		Role:      role,
+		Household: household,
 	}, nil
 }
 
-func LoadCalendarForMonth(year, month int) (Calendar, error) {
-	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	firstDay := start
-	weekday := int(start.Weekday())
-	start = start.AddDate(0, 0, -1*weekday)
-	end := start.AddDate(0, 0, 35) // always fetching 5 full weeks
+func LoadCalendarForMonth(year, month int, firstWeekday time.Weekday, resourceID int) (Calendar, error) {
+	resource, resources, err := ResolveResource(resourceID)
+	if err != nil {
+		return Calendar{}, err
+	}
+	firstDay := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	start, weekCount := gridRange(year, month, firstWeekday)
+	end := start.AddDate(0, 0, weekCount*7-1)
 
-	entries, err := loadEntries(start, end)
+	scope := conflictScope(resources, resource.ID)
+	entries, err := loadEntries(scope, start, end)
 	if err != nil {
 		return Calendar{}, fmt.Errorf("error loading entries (%d, %d): %w", year, month, err)
 	}
+	blocks, err := loadBlocks(scope, start, end)
+	if err != nil {
+		return Calendar{}, err
+	}
 	log.Default().Printf("found %d entries", len(entries))
-
-	currDay := start
-	weeks := make([][]Day, 0, 5)
-	for i := 0; i < 5; i++ {
-		weeks = append(weeks, make([]Day, 0, 7))
+	for i := range entries {
+		entries[i].Year = year
+		entries[i].Month = month
+	}
+	err = attachGuests(entries)
+	if err != nil {
+		return Calendar{}, err
 	}
-	entryIdx := 0
-	for i := 0; i < 35; i++ {
-		d := Day{
-			DayOfMonth: currDay.Day(),
-			Month:      month,
-		}
-		hasEntry := false
-		isOwn := false
-		if len(entries) > entryIdx {
-			entry := entries[entryIdx]
-			if (currDay.Equal(entry.Begin) || currDay.After(entry.Begin)) && (currDay.Equal(entry.End) || currDay.Before(entry.End)) {
-				d.Entry = entry
-				hasEntry = true
-				isOwn = entry.IsOwn
-			}
-			if currDay.Equal(entry.End) {
-				entryIdx++
-			}
-		}
-
-		d.Classname = getClassname(month, currDay, hasEntry, isOwn)
-
-		currDay = currDay.AddDate(0, 0, 1)
 
-		weekIdx := i / 7
-		weeks[weekIdx] = append(weeks[weekIdx], d)
+	weeks := buildWeeks(year, month, firstWeekday, entries)
+	if resource.Capacity > 0 {
+		applyCapacity(weeks, entries, resource.Capacity)
 	}
+	applyBlocks(weeks, blocks)
+	applyHolidays(weeks, loadCalendarHolidays(start, end))
 	return Calendar{
 		PrevYear:       firstDay.AddDate(-1, 0, 0).Year(),
 		Year:           year,
@@ -227,11 +538,16 @@ func LoadCalendarForMonth(year, month int) (Calendar, error) {
 		NextMonth:      int(firstDay.AddDate(0, 1, 0).Month()),
 		NextMonthName:  GermanMonths[int(firstDay.AddDate(0, 1, 0).Month())],
 		MonthYear:      fmt.Sprintf("%s %d", GermanMonths[month], year),
+		Resource:       resource,
+		Resources:      resources,
 		Weeks:          weeks,
+		WeekdayNames:   weekdayNames(firstWeekday),
 		AllDaysInMonth: getAllDaysInMonth(),
 		AllMonths:      []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
-		AllYears:       getAllYears(year),
+		AllYears:       allowedYears(time.Now()),
 		AllEntries:     entries,
+		AllBlocks:      blocks,
+		Legend:         buildLegend(entries),
 	}, nil
 }
 
@@ -255,15 +571,6 @@ func getClassname(month int, date time.Time, hasEntry bool, isOwn bool) string {
 	return ""
 }
 
-func getAllYears(year int) []int {
-	years := make([]int, 0, 4)
-	for i := 0; i < 4; i++ {
-		years = append(years, year)
-		year = year + 1
-	}
-	return years
-}
-
 func getAllDaysInMonth() []int {
 	days := []int{}
 	for day := 1; day <= 31; day++ {
@@ -272,10 +579,13 @@ func getAllDaysInMonth() []int {
 	return days
 }
 
-func loadEntries(start, end time.Time) ([]Entry, error) {
-	user := middleware.Session.Get("username")
+// loadEntries loads all entries of the given resources overlapping the range.
+// Recurring entries are expanded into their occurrences.
+func loadEntries(resourceIDs []int, start, end time.Time) ([]Entry, error) {
 	entries := make([]Entry, 0, 35)
-	dbres, err := middleware.DB.Query("select res_id, user, begin, end, bemerkungen from entries where end >= ? and begin <= ? order by begin asc", start, end)
+	in, args := inClause(resourceIDs)
+	args = append(args, start, end)
+	dbres, err := middleware.DB.Query("select "+entryColumns+" from "+entryTables+" where e.resource_id in "+in+" and "+activeStatusCond+" and "+notDeletedCond+" and (e.end >= ? or e.rrule <> '') and e.begin <= ? order by e.begin asc", args...)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching query: %w", err)
 	}
@@ -286,19 +596,14 @@ func loadEntries(start, end time.Time) ([]Entry, error) {
 	defer dbres.Close()
 
 	for dbres.Next() {
-		var entry Entry
-		err = dbres.Scan(&entry.ID, &entry.User, &entry.Begin, &entry.End, &entry.Bemerkungen)
+		entry, err := scanEntry(dbres)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning row: %w", err)
-		}
-		if entry.User == user {
-			entry.IsOwn = true
+			return nil, err
 		}
-		entry.Year = start.Year()
-		entry.Month = int(start.Month()) + 1
+		entry.IsOwn = isOwn(entry)
 		entries = append(entries, entry)
 	}
-	return entries, nil
+	return expandEntries(entries, start, end)
 }
 
 func daysIn(m time.Month, year int) int {
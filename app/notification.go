@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// MailConfig enables sending notifications by mail. Mails are only sent if
+// Host is set.
+type MailConfig struct {
+	Host     string // host:port
+	User     string
+	Password string
+	From     string
+}
+
+var Mail MailConfig
+
+type Notification struct {
+	ID        int
+	User      string
+	Message   string
+	CreatedAt time.Time
+}
+
+// Notify stores a message for the user and mails it if possible. Failing to
+// notify is logged but never fails the action causing the notification.
+func Notify(user, message string) {
+	_, err := middleware.DB.Exec("insert into notifications (user, message, created_at) values (?,?,?)", user, message, time.Now())
+	if err != nil {
+		log.Default().Printf("error storing notification for %s: %s", user, err)
+	}
+	if Mail.Host == "" {
+		return
+	}
+	u, err := LoadUser(user)
+	if err != nil {
+		log.Default().Printf("error loading user %s to send mail: %s", user, err)
+		return
+	}
+	if u.Email == "" {
+		return
+	}
+	err = sendMail(u.Email, message)
+	if err != nil {
+		log.Default().Printf("error sending mail to %s: %s", u.Email, err)
+	}
+}
+
+func sendMail(to, message string) error {
+	var auth smtp.Auth
+	if Mail.User != "" {
+		host := strings.Split(Mail.Host, ":")[0]
+		auth = smtp.PlainAuth("", Mail.User, Mail.Password, host)
+	}
+	msg := strings.Join([]string{
+		"From: " + Mail.From,
+		"To: " + to,
+		"Subject: Reservationen",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		message,
+	}, "\r\n")
+	return smtp.SendMail(Mail.Host, auth, Mail.From, []string{to}, []byte(msg))
+}
+
+// PopNotifications returns the unread notifications of the user and marks
+// them as read.
+func PopNotifications(user string) ([]Notification, error) {
+	rows, err := middleware.DB.Query("select id, user, message, created_at from notifications where user = ? and read_at is null order by id", user)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var n Notification
+		err = rows.Scan(&n.ID, &n.User, &n.Message, &n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching notifications: %w", rows.Err())
+	}
+	if len(notifications) == 0 {
+		return notifications, nil
+	}
+
+	last := notifications[len(notifications)-1].ID
+	_, err = middleware.DB.Exec("update notifications set read_at = ? where user = ? and read_at is null and id <= ?", time.Now(), user, last)
+	if err != nil {
+		return nil, fmt.Errorf("error marking notifications as read: %w", err)
+	}
+	return notifications, nil
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595 // A4 in points
+	pdfPageHeight = 842
+	pdfMargin     = 56
+)
+
+// pdfCell is a piece of text on a line, left aligned at X or right aligned
+// to X.
+type pdfCell struct {
+	X     float64
+	Text  string
+	Right bool
+}
+
+// pdfDoc writes simple text documents as PDF. It only uses the standard
+// fonts every viewer has, so nothing has to be embedded.
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// Line writes the cells on the next line and starts a new page when the
+// current one is full.
+func (d *pdfDoc) Line(size float64, bold bool, cells ...pdfCell) {
+	if len(d.pages) == 0 || d.y-size < pdfMargin {
+		d.pages = append(d.pages, &bytes.Buffer{})
+		d.y = pdfPageHeight - pdfMargin
+	}
+	d.y -= size * 1.4
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	page := d.pages[len(d.pages)-1]
+	for _, c := range cells {
+		x := c.X
+		if c.Right {
+			x -= pdfTextWidth(c.Text, size)
+		}
+		fmt.Fprintf(page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, pdfEscape(c.Text))
+	}
+}
+
+// Skip leaves the space of an empty line.
+func (d *pdfDoc) Skip(size float64) {
+	d.Line(size, false)
+}
+
+// Bytes assembles the document with its cross reference table.
+func (d *pdfDoc) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.Skip(10)
+	}
+	objects := []string{
+		"", // catalog, filled in below
+		"", // page tree
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	kids := []string{}
+	for _, content := range d.pages {
+		pageID := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, pageID+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// pdfEscape encodes the text in WinAnsiEncoding, which matches Latin-1 for
+// umlauts, and escapes the string delimiters.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteByte(0x80)
+		case r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case r >= 0x20 && r < 0x7F:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfTextWidth measures text in Helvetica. It is exact for digits, which
+// all have the same width, and therefore for amounts; other text is
+// estimated.
+func pdfTextWidth(s string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		switch r {
+		case '.', ',', ' ':
+			units += 278
+		case '-':
+			units += 333
+		default:
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
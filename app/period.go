@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// MonthDay is a day of the year without the year, e.g. 12-24.
+type MonthDay struct {
+	Month time.Month
+	Day   int
+}
+
+func ParseMonthDay(s string) (MonthDay, error) {
+	t, err := time.Parse("01-02", s)
+	if err != nil {
+		return MonthDay{}, fmt.Errorf("invalid month and day (%s): %w", s, err)
+	}
+	return MonthDay{Month: t.Month(), Day: t.Day()}, nil
+}
+
+func (md MonthDay) String() string {
+	return fmt.Sprintf("%02d-%02d", int(md.Month), md.Day)
+}
+
+func (md MonthDay) In(year int) time.Time {
+	return time.Date(year, md.Month, md.Day, 0, 0, 0, 0, time.UTC)
+}
+
+func (md MonthDay) before(other MonthDay) bool {
+	return md.Month < other.Month || (md.Month == other.Month && md.Day < other.Day)
+}
+
+// Period is a range of days recurring every year. Periods may span the new
+// year, e.g. 12-20 to 01-06.
+type Period struct {
+	Label string
+	Start MonthDay
+	End   MonthDay
+}
+
+func ParsePeriod(label, start, end string) (Period, error) {
+	s, err := ParseMonthDay(start)
+	if err != nil {
+		return Period{}, err
+	}
+	e, err := ParseMonthDay(end)
+	if err != nil {
+		return Period{}, err
+	}
+	return Period{Label: label, Start: s, End: e}, nil
+}
+
+func (p Period) wraps() bool {
+	return p.End.before(p.Start)
+}
+
+func (p Period) Contains(day time.Time) bool {
+	md := MonthDay{Month: day.Month(), Day: day.Day()}
+	if p.wraps() {
+		return !md.before(p.Start) || !p.End.before(md)
+	}
+	return !md.before(p.Start) && !p.End.before(md)
+}
+
+// Overlaps reports whether any day from begin to end (inclusive) lies within
+// the period.
+func (p Period) Overlaps(begin, end time.Time) bool {
+	for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if p.Contains(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Occurrence returns the dates of the period starting in the given year.
+func (p Period) Occurrence(year int) (time.Time, time.Time) {
+	endYear := year
+	if p.wraps() {
+		endYear++
+	}
+	return p.Start.In(year), p.End.In(endYear)
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%s (%s - %s)", p.Label, p.Start, p.End)
+}
@@ -0,0 +1,98 @@
+package app
+
+import "testing"
+
+func TestPeriodContains(t *testing.T) {
+	christmas, err := ParsePeriod("Weihnachten", "12-20", "01-06")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summer, err := ParsePeriod("Sommer", "07-01", "08-15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		period Period
+		day    int
+		month  int
+		want   bool
+	}{
+		{christmas, 19, 12, false},
+		{christmas, 20, 12, true},
+		{christmas, 31, 12, true},
+		{christmas, 1, 1, true},
+		{christmas, 6, 1, true},
+		{christmas, 7, 1, false},
+		{christmas, 1, 7, false},
+		{summer, 30, 6, false},
+		{summer, 1, 7, true},
+		{summer, 15, 8, true},
+		{summer, 16, 8, false},
+	}
+	for _, tt := range tests {
+		if got := tt.period.Contains(date(2024, tt.month, tt.day)); got != tt.want {
+			t.Errorf("%s contains %02d.%02d: expected %t", tt.period, tt.day, tt.month, tt.want)
+		}
+	}
+}
+
+func TestPeriodOverlapsAndOccurrence(t *testing.T) {
+	christmas, _ := ParsePeriod("Weihnachten", "12-20", "01-06")
+	if !christmas.Overlaps(date(2024, 12, 10), date(2024, 12, 20)) {
+		t.Error("expected overlap on first day")
+	}
+	if christmas.Overlaps(date(2024, 1, 7), date(2024, 12, 19)) {
+		t.Error("expected no overlap between occurrences")
+	}
+	begin, end := christmas.Occurrence(2024)
+	if !begin.Equal(date(2024, 12, 20)) || !end.Equal(date(2025, 1, 6)) {
+		t.Errorf("unexpected occurrence %s - %s", begin, end)
+	}
+	if _, err := ParseMonthDay("13-01"); err == nil {
+		t.Error("expected error for invalid month")
+	}
+}
+
+func TestRequiresApproval(t *testing.T) {
+	christmas, _ := ParsePeriod("Weihnachten", "12-20", "01-06")
+	rules := []ApprovalRule{{ResourceID: 2, Period: christmas}}
+	if !requiresApproval(rules, Entry{ResourceID: 2, Begin: date(2024, 12, 27), End: date(2025, 1, 2)}) {
+		t.Error("expected approval for christmas on resource 2")
+	}
+	if requiresApproval(rules, Entry{ResourceID: 1, Begin: date(2024, 12, 27), End: date(2025, 1, 2)}) {
+		t.Error("expected no approval on resource 1")
+	}
+	rules[0].ResourceID = 0
+	if !requiresApproval(rules, Entry{ResourceID: 1, Begin: date(2024, 12, 27), End: date(2025, 1, 2)}) {
+		t.Error("expected approval on all resources")
+	}
+}
+
+func TestStatusChangeAllowed(t *testing.T) {
+	admin := User{Name: "frank", Role: RoleAdmin}
+	anna := User{Name: "anna"}
+	requested := Entry{User: "anna", Status: StatusRequested}
+	confirmed := Entry{User: "anna", Status: StatusConfirmed}
+	declined := Entry{User: "anna", Status: StatusDeclined}
+
+	tests := []struct {
+		entry  Entry
+		status string
+		actor  User
+		want   bool
+	}{
+		{requested, StatusConfirmed, admin, true},
+		{requested, StatusConfirmed, anna, false},
+		{confirmed, StatusDeclined, admin, false},
+		{requested, StatusCancelled, anna, true},
+		{confirmed, StatusCancelled, User{Name: "peter"}, false},
+		{Entry{User: "anna", HouseholdID: 3, Status: StatusConfirmed}, StatusCancelled, User{Name: "peter", Household: 3}, true},
+		{declined, StatusCancelled, anna, false},
+		{requested, StatusRequested, admin, false},
+	}
+	for i, tt := range tests {
+		if got := statusChangeAllowed(tt.entry, tt.status, tt.actor); got != tt.want {
+			t.Errorf("case %d: expected %t", i, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrPolicy = errors.New("booking policy violated")
+
+// Policy restricts which entries users may book. Zero values disable a rule.
+type Policy struct {
+	MinNights         int
+	MaxNights         int
+	MinNoticeDays     int       // days between today and the begin of the stay
+	MaxHorizonDays    int       // how many days ahead the stay may end
+	NightsPerYear     int       // quota per household (or user) and calendar year
+	PeakNightsPerYear int       // quota per household (or user) and calendar year within peak periods
+	PeakPeriods       []Period  // periods counting against PeakNightsPerYear
+	PeakHolidays      []Holiday // holidays counting against PeakNightsPerYear as well
+}
+
+// BookingPolicy is applied to all new and changed entries.
+var BookingPolicy Policy
+
+// PolicyError lists all rules a booking violates in german.
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "Reservation nicht möglich: " + strings.Join(e.Violations, " ")
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicy
+}
+
+// ParsePeriods reads periods in the form "Weihnachten:12-20:01-06,Ski:02-01:02-28".
+func ParsePeriods(s string) ([]Period, error) {
+	periods := []Period{}
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		parts := strings.Split(p, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid period (%s), expected label:MM-DD:MM-DD", p)
+		}
+		period, err := ParsePeriod(parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
+
+func (p Policy) isPeak(day time.Time) bool {
+	for _, period := range p.PeakPeriods {
+		if period.Contains(day) {
+			return true
+		}
+	}
+	for _, h := range p.PeakHolidays {
+		if h.contains(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the entry against all rules. booked contains the other
+// active entries counting against the same quota, with recurring entries
+// expanded. Recurring entries must end and are checked in every occurrence.
+// It returns nil if the entry may be booked.
+func (p Policy) Validate(entry Entry, booked []Entry, now time.Time) error {
+	return p.validate(entry, nil, p.occurrences(entry, now, nil), booked, now)
+}
+
+// ValidateChange checks the changed version of an existing entry. The rules
+// on the past, notice and horizon only apply to dates which changed, so an
+// ongoing stay can still be edited or shortened.
+func (p Policy) ValidateChange(entry, existing Entry, booked []Entry, now time.Time) error {
+	return p.validate(entry, &existing, p.occurrences(entry, now, nil), booked, now)
+}
+
+// occurrences returns the stays of the entry counting against the quotas.
+// Series are expanded up to the horizon, beyond it they are rejected anyway.
+func (p Policy) occurrences(entry Entry, now time.Time, skipped []time.Time) []Entry {
+	if !entry.IsRecurring() {
+		return []Entry{entry}
+	}
+	to := now.AddDate(recurrenceHorizonYears, 0, 0)
+	if p.MaxHorizonDays > 0 {
+		to = now.AddDate(0, 0, p.MaxHorizonDays)
+	}
+	if to.Before(entry.End) {
+		to = entry.End
+	}
+	return entry.occurrences(entry.Begin, to, skipped)
+}
+
+func (p Policy) validate(entry Entry, existing *Entry, occurrences []Entry, booked []Entry, now time.Time) error {
+	violations := []string{}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	beginChanged := existing == nil || !entry.Begin.Equal(existing.Begin)
+	endChanged := existing == nil || !entry.End.Equal(existing.End) || entry.Recurrence != existing.Recurrence
+
+	if entry.End.Before(entry.Begin) {
+		violations = append(violations, "Das Enddatum liegt vor dem Anfangsdatum.")
+	}
+	// the horizon applies to the end of the last occurrence of a series
+	lastEnd := entry.End
+	if entry.IsRecurring() {
+		rule, err := ParseRule(entry.Recurrence)
+		last, ok := rule.lastBegin(entry.Begin)
+		switch {
+		case err != nil:
+			violations = append(violations, "Die Wiederholung ist ungültig.")
+		case !ok:
+			violations = append(violations, "Wiederholungen brauchen eine Anzahl oder ein Enddatum.")
+		default:
+			lastEnd = last.Add(entry.End.Sub(entry.Begin))
+		}
+	}
+	if beginChanged && entry.Begin.Before(today) {
+		violations = append(violations, "Das Anfangsdatum liegt in der Vergangenheit.")
+	}
+	nights := nightsWhere(entry, func(time.Time) bool { return true })
+	if p.MinNights > 0 && nights < p.MinNights {
+		violations = append(violations, fmt.Sprintf("Der Aufenthalt muss mindestens %d Nächte dauern.", p.MinNights))
+	}
+	if p.MaxNights > 0 && nights > p.MaxNights {
+		violations = append(violations, fmt.Sprintf("Der Aufenthalt darf höchstens %d Nächte dauern.", p.MaxNights))
+	}
+	if beginChanged && p.MinNoticeDays > 0 && entry.Begin.Before(today.AddDate(0, 0, p.MinNoticeDays)) {
+		violations = append(violations, fmt.Sprintf("Reservationen müssen mindestens %d Tage im Voraus erfolgen.", p.MinNoticeDays))
+	}
+	if endChanged && p.MaxHorizonDays > 0 && lastEnd.After(today.AddDate(0, 0, p.MaxHorizonDays)) {
+		violations = append(violations, fmt.Sprintf("Reservationen sind höchstens %d Tage im Voraus möglich (bis %s).", p.MaxHorizonDays, today.AddDate(0, 0, p.MaxHorizonDays).Format("02.01.2006")))
+	}
+
+	lastYear := entry.End.Year()
+	if len(occurrences) > 0 {
+		lastYear = occurrences[len(occurrences)-1].End.Year()
+	}
+	for year := entry.Begin.Year(); year <= lastYear; year++ {
+		inYear := func(day time.Time) bool { return day.Year() == year }
+		if p.NightsPerYear > 0 {
+			violations = append(violations, quotaViolation(occurrences, booked, inYear, p.NightsPerYear, fmt.Sprintf("Nächten im Jahr %d", year))...)
+		}
+		if p.PeakNightsPerYear > 0 {
+			inPeak := func(day time.Time) bool { return inYear(day) && p.isPeak(day) }
+			violations = append(violations, quotaViolation(occurrences, booked, inPeak, p.PeakNightsPerYear, fmt.Sprintf("Nächten in der Hochsaison %d", year))...)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func quotaViolation(requestedStays []Entry, booked []Entry, counts func(time.Time) bool, quota int, what string) []string {
+	requested := 0
+	for _, e := range requestedStays {
+		requested += nightsWhere(e, counts)
+	}
+	if requested == 0 {
+		return nil
+	}
+	used := 0
+	for _, e := range booked {
+		used += nightsWhere(e, counts)
+	}
+	if used+requested <= quota {
+		return nil
+	}
+	return []string{fmt.Sprintf("Das Kontingent von %d %s ist überschritten (bereits %d reserviert, %d angefragt).", quota, what, used, requested)}
+}
+
+// nightsWhere counts the nights of the entry for which counts returns true.
+func nightsWhere(entry Entry, counts func(time.Time) bool) int {
+	nights := 0
+	for day := entry.Begin; day.Before(entry.End); day = day.AddDate(0, 0, 1) {
+		if counts(day) {
+			nights++
+		}
+	}
+	return nights
+}
+
+// checkPolicy validates the entry against BookingPolicy and the other entries
+// of the same household or, for users without household, the same user.
+// existing is the stored version of a changed entry, nil for new ones.
+// Recurring entries and the booked ones are validated in all occurrences.
+// Entries in released are given up with the change and not counted.
+func checkPolicy(entry Entry, existing *Entry, released ...int) error {
+	now := time.Now()
+	skipped := map[int][]time.Time{}
+	if entry.IsRecurring() && entry.ID != 0 {
+		var err error
+		skipped, err = loadSkipped("entry_exceptions", "entry_id", []int{entry.ID})
+		if err != nil {
+			return err
+		}
+	}
+	occurrences := BookingPolicy.occurrences(entry, now, skipped[entry.ID])
+	last := entry
+	if len(occurrences) > 0 {
+		last = occurrences[len(occurrences)-1]
+	}
+	filter := EntryFilter{
+		From: time.Date(entry.Begin.Year(), 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(last.End.Year(), 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	if entry.HouseholdID != 0 {
+		filter.Household = entry.HouseholdID
+	} else {
+		filter.User = entry.User
+	}
+	entries, err := queryAllEntries(filter, "e.begin asc")
+	if err != nil {
+		return fmt.Errorf("error loading entries for quota: %w", err)
+	}
+	others := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.ID != entry.ID && !slices.Contains(released, e.ID) {
+			others = append(others, e)
+		}
+	}
+	booked, err := expandEntries(others, filter.From, filter.To)
+	if err != nil {
+		return err
+	}
+	return BookingPolicy.validate(entry, existing, occurrences, booked, now)
+}
+
+// allowedYears returns the years which can be booked from now on.
+func allowedYears(now time.Time) []int {
+	last := now.Year() + 3
+	if BookingPolicy.MaxHorizonDays > 0 {
+		last = now.AddDate(0, 0, BookingPolicy.MaxHorizonDays).Year()
+	}
+	years := make([]int, 0, last-now.Year()+1)
+	for year := now.Year(); year <= last; year++ {
+		years = append(years, year)
+	}
+	return years
+}
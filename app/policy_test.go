@@ -0,0 +1,151 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	christmas, _ := ParsePeriod("Weihnachten", "12-20", "01-06")
+	policy := Policy{
+		MinNights:         2,
+		MaxNights:         14,
+		MinNoticeDays:     3,
+		MaxHorizonDays:    365,
+		NightsPerYear:     30,
+		PeakNightsPerYear: 7,
+		PeakPeriods:       []Period{christmas},
+	}
+	now := time.Date(2024, 6, 1, 15, 30, 0, 0, time.UTC)
+	booked := []Entry{
+		{Begin: date(2024, 7, 1), End: date(2024, 7, 15)},
+		{Begin: date(2024, 12, 20), End: date(2024, 12, 24)},
+	}
+
+	tests := []struct {
+		name       string
+		begin, end time.Time
+		violations []string
+	}{
+		{"valid", date(2024, 8, 1), date(2024, 8, 8), nil},
+		{"end before begin", date(2024, 8, 8), date(2024, 8, 1), []string{"vor dem Anfangsdatum", "mindestens 2 Nächte"}},
+		{"in the past", date(2024, 5, 1), date(2024, 5, 5), []string{"Vergangenheit", "3 Tage im Voraus"}},
+		{"too short", date(2024, 8, 1), date(2024, 8, 2), []string{"mindestens 2 Nächte"}},
+		{"too long and over quota", date(2024, 8, 1), date(2024, 8, 20), []string{"höchstens 14 Nächte", "30 Nächten im Jahr 2024"}},
+		{"short notice", date(2024, 6, 2), date(2024, 6, 5), []string{"3 Tage im Voraus"}},
+		{"beyond horizon", date(2025, 5, 30), date(2025, 6, 5), []string{"365 Tage im Voraus"}},
+		{"yearly quota", date(2024, 9, 1), date(2024, 9, 14), []string{"30 Nächten im Jahr 2024"}},
+		{"peak quota", date(2024, 12, 26), date(2024, 12, 30), []string{"Hochsaison 2024"}},
+		{"peak quota of next year is separate", date(2024, 12, 31), date(2025, 1, 4), nil},
+	}
+	for _, tt := range tests {
+		err := policy.Validate(Entry{Begin: tt.begin, End: tt.end}, booked, now)
+		if len(tt.violations) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", tt.name, err)
+			}
+			continue
+		}
+		var policyErr *PolicyError
+		if !errors.As(err, &policyErr) || !errors.Is(err, ErrPolicy) {
+			t.Errorf("%s: expected policy error, got %v", tt.name, err)
+			continue
+		}
+		if len(policyErr.Violations) != len(tt.violations) {
+			t.Errorf("%s: expected %d violations, got %v", tt.name, len(tt.violations), policyErr.Violations)
+			continue
+		}
+		for i, v := range tt.violations {
+			if !strings.Contains(policyErr.Violations[i], v) {
+				t.Errorf("%s: expected violation containing %q, got %q", tt.name, v, policyErr.Violations[i])
+			}
+		}
+	}
+}
+
+func TestPolicyValidateSeries(t *testing.T) {
+	policy := Policy{MaxHorizonDays: 365, NightsPerYear: 30}
+	now := time.Date(2024, 6, 1, 15, 30, 0, 0, time.UTC)
+	booked := []Entry{{Begin: date(2024, 7, 1), End: date(2024, 7, 15)}}
+	week := func(rule string) Entry {
+		return Entry{Begin: date(2024, 8, 3), End: date(2024, 8, 6), Recurrence: rule}
+	}
+
+	tests := []struct {
+		name      string
+		entry     Entry
+		violation string
+	}{
+		{"ending series within quota", week("FREQ=WEEKLY;COUNT=5"), ""},
+		{"open ended series", week("FREQ=WEEKLY"), "Anzahl oder ein Enddatum"},
+		{"quota counts every occurrence", week("FREQ=WEEKLY;COUNT=6"), "30 Nächten im Jahr 2024"},
+		{"last occurrence beyond horizon", week("FREQ=YEARLY;COUNT=2"), "365 Tage im Voraus"},
+		{"until beyond horizon", week("FREQ=MONTHLY;UNTIL=20250801"), "365 Tage im Voraus"},
+		{"invalid rule", week("FREQ=DAILY;COUNT=2"), "ungültig"},
+	}
+	for _, tt := range tests {
+		err := policy.Validate(tt.entry, booked, now)
+		if tt.violation == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %s", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.violation) {
+			t.Errorf("%s: expected violation containing %q, got %v", tt.name, tt.violation, err)
+		}
+	}
+}
+
+func TestPolicyValidateChange(t *testing.T) {
+	policy := Policy{MinNights: 2, MinNoticeDays: 3, MaxHorizonDays: 365}
+	now := time.Date(2024, 6, 1, 15, 30, 0, 0, time.UTC)
+	ongoing := Entry{ID: 1, Begin: date(2024, 5, 28), End: date(2024, 6, 8)}
+
+	shortened := Entry{ID: 1, Begin: date(2024, 5, 28), End: date(2024, 6, 4)}
+	if err := policy.ValidateChange(shortened, ongoing, nil, now); err != nil {
+		t.Errorf("shortening an ongoing stay: unexpected error %s", err)
+	}
+	if err := policy.Validate(shortened, nil, now); err == nil {
+		t.Error("expected a new stay in the past to be rejected")
+	}
+	moved := Entry{ID: 1, Begin: date(2024, 5, 29), End: date(2024, 6, 8)}
+	if err := policy.ValidateChange(moved, ongoing, nil, now); err == nil {
+		t.Error("expected moving the begin into the past to be rejected")
+	}
+	extended := Entry{ID: 1, Begin: date(2024, 5, 28), End: date(2025, 6, 8)}
+	if err := policy.ValidateChange(extended, ongoing, nil, now); err == nil {
+		t.Error("expected extending beyond the horizon to be rejected")
+	}
+	tooShort := Entry{ID: 1, Begin: date(2024, 5, 28), End: date(2024, 5, 29)}
+	if err := policy.ValidateChange(tooShort, ongoing, nil, now); err == nil {
+		t.Error("expected the minimum stay to apply to changes")
+	}
+}
+
+func TestParsePeriods(t *testing.T) {
+	periods, err := ParsePeriods("Weihnachten:12-20:01-06, Ski:02-01:02-28")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(periods) != 2 || periods[1].Label != "Ski" || periods[1].End.Day != 28 {
+		t.Errorf("unexpected periods %v", periods)
+	}
+	if _, err := ParsePeriods("Ski:02-01"); err == nil {
+		t.Error("expected error for incomplete period")
+	}
+	if periods, _ := ParsePeriods(""); len(periods) != 0 {
+		t.Error("expected no periods")
+	}
+}
+
+func TestAllowedYears(t *testing.T) {
+	BookingPolicy = Policy{MaxHorizonDays: 400}
+	defer func() { BookingPolicy = Policy{} }()
+	years := allowedYears(date(2024, 12, 1))
+	if len(years) != 3 || years[0] != 2024 || years[2] != 2026 {
+		t.Errorf("unexpected years %v", years)
+	}
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Money is an amount in cents.
+type Money int64
+
+// ParseMoney reads amounts like "45", "45.5", "45.50" or "-12.50".
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	sign := Money(1)
+	digits := s
+	if rest, ok := strings.CutPrefix(digits, "-"); ok {
+		sign, digits = -1, rest
+	}
+	whole, frac, _ := strings.Cut(digits, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid amount (%s): more than two decimals", s)
+	}
+	frac = (frac + "00")[:2]
+	w, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount (%s): %w", s, err)
+	}
+	f, err := strconv.ParseUint(frac, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount (%s): %w", s, err)
+	}
+	return sign * Money(w*100+f), nil
+}
+
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+
+// SeasonRate overrides the default nightly prices within its period.
+type SeasonRate struct {
+	Period Period
+	Adult  Money
+	Child  Money
+}
+
+// Pricing computes what members pay into the house fund per entry.
+type Pricing struct {
+	Adult            Money        // per adult and night outside of seasons
+	Child            Money        // per child and night outside of seasons
+	Seasons          []SeasonRate // the first season containing a night wins
+	WeekendSurcharge int          // percent added to friday and saturday nights
+	CleaningFee      Money        // once per stay
+}
+
+// Prices is applied to all new and changed entries.
+var Prices Pricing
+
+// ParseSeasonRates reads seasons in the form
+// "Hochsaison:12-20:01-06:45:20,Sommer:07-01:08-31:40:15" with the prices per
+// adult and child.
+func ParseSeasonRates(s string) ([]SeasonRate, error) {
+	seasons := []SeasonRate{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("invalid season (%s), expected label:MM-DD:MM-DD:adult:child", part)
+		}
+		period, err := ParsePeriod(fields[0], fields[1], fields[2])
+		if err != nil {
+			return nil, err
+		}
+		adult, err := ParseMoney(fields[3])
+		if err != nil {
+			return nil, err
+		}
+		child, err := ParseMoney(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, SeasonRate{Period: period, Adult: adult, Child: child})
+	}
+	return seasons, nil
+}
+
+func (p Pricing) ratesOn(day time.Time) (Money, Money) {
+	for _, s := range p.Seasons {
+		if s.Period.Contains(day) {
+			return s.Adult, s.Child
+		}
+	}
+	return p.Adult, p.Child
+}
+
+// Cost sums up the nights of the entry and the cleaning fee.
+func (p Pricing) Cost(entry Entry) Money {
+	var total Money
+	nights := 0
+	for day := entry.Begin; day.Before(entry.End); day = day.AddDate(0, 0, 1) {
+		adult, child := p.ratesOn(day)
+		night := Money(entry.Adults())*adult + Money(entry.Children)*child
+		if wd := day.Weekday(); wd == time.Friday || wd == time.Saturday {
+			night += night * Money(p.WeekendSurcharge) / 100
+		}
+		total += night
+		nights++
+	}
+	if nights > 0 {
+		total += p.CleaningFee
+	}
+	return total
+}
+
+// Adults returns the number of guests which are not children.
+func (e Entry) Adults() int {
+	if e.Children > e.Guests {
+		return 0
+	}
+	return e.Guests - e.Children
+}
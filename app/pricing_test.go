@@ -0,0 +1,93 @@
+package app
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := map[string]Money{
+		"":       0,
+		"45":     4500,
+		"45.5":   4550,
+		"45.05":  4505,
+		"0.99":   99,
+		"-12.50": -1250,
+		"-0.5":   -50,
+	}
+	for in, want := range tests {
+		got, err := ParseMoney(in)
+		if err != nil || got != want {
+			t.Errorf("%q: expected %d, got %d (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"abc", "1.234", "1.x", "--1", "1.-5", "-"} {
+		if _, err := ParseMoney(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+	if s := Money(-1205).String(); s != "-12.05" {
+		t.Errorf("unexpected %s", s)
+	}
+}
+
+func TestPricingCost(t *testing.T) {
+	seasons, err := ParseSeasonRates("Weihnachten:12-20:01-06:50:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Pricing{
+		Adult:            Money(3000),
+		Child:            Money(1000),
+		Seasons:          seasons,
+		WeekendSurcharge: 10,
+		CleaningFee:      Money(8000),
+	}
+	tests := []struct {
+		entry Entry
+		want  Money
+	}{
+		// monday to wednesday, two adults: 2 nights * 60 + cleaning
+		{Entry{Begin: date(2024, 3, 4), End: date(2024, 3, 6), Guests: 2}, 2*6000 + 8000},
+		// one adult and one child
+		{Entry{Begin: date(2024, 3, 4), End: date(2024, 3, 5), Guests: 2, Children: 1}, 4000 + 8000},
+		// friday and saturday night with surcharge
+		{Entry{Begin: date(2024, 3, 8), End: date(2024, 3, 10), Guests: 1}, 2*3300 + 8000},
+		// season over new year: thursday 2024-12-19 is regular, then season
+		{Entry{Begin: date(2024, 12, 19), End: date(2024, 12, 21), Guests: 1}, 3000 + 5500 + 8000},
+		// no nights, no cleaning
+		{Entry{Begin: date(2024, 3, 4), End: date(2024, 3, 4), Guests: 1}, 0},
+	}
+	for i, tt := range tests {
+		if got := p.Cost(tt.entry); got != tt.want {
+			t.Errorf("case %d: expected %s, got %s", i, tt.want, got)
+		}
+	}
+}
+
+func TestOccurrenceCost(t *testing.T) {
+	seasons, err := ParseSeasonRates("Weihnachten:12-20:01-06:50:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(p Pricing) { Prices = p }(Prices)
+	Prices = Pricing{Adult: Money(3000), Child: Money(1000), Seasons: seasons, CleaningFee: Money(8000)}
+
+	// every monday for one night, the second one in the season
+	e := Entry{Begin: date(2024, 12, 16), End: date(2024, 12, 17), Guests: 1, Recurrence: "FREQ=WEEKLY;COUNT=2"}
+	e.Cost = Prices.Cost(e)
+	occurrences := e.occurrences(date(2024, 12, 1), date(2024, 12, 31), nil)
+	if len(occurrences) != 2 {
+		t.Fatalf("unexpected occurrences %+v", occurrences)
+	}
+	if occurrences[0].Cost != 3000+8000 || occurrences[1].Cost != 5000+8000 {
+		t.Errorf("unexpected costs %s and %s", occurrences[0].Cost, occurrences[1].Cost)
+	}
+}
+
+func TestParseSeasonRates(t *testing.T) {
+	if _, err := ParseSeasonRates("Sommer:07-01:08-31:40"); err == nil {
+		t.Error("expected error for missing child rate")
+	}
+	seasons, err := ParseSeasonRates(" Sommer:07-01:08-31:40:15.50 ,")
+	if err != nil || len(seasons) != 1 || seasons[0].Adult != 4000 || seasons[0].Child != 1550 {
+		t.Errorf("unexpected seasons %+v (%v)", seasons, err)
+	}
+}
@@ -0,0 +1,404 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+
+	// recurrenceHorizonYears limits the occurrences checked for series
+	// without COUNT or UNTIL if the policy has no horizon.
+	recurrenceHorizonYears = 3
+)
+
+// Rule is the subset of RFC 5545 recurrence rules the calendar supports,
+// e.g. "FREQ=MONTHLY;INTERVAL=2;COUNT=6".
+type Rule struct {
+	Freq     string
+	Interval int       // 1 if not given
+	Count    int       // number of occurrences, unlimited if 0
+	Until    time.Time // last possible begin, unlimited if zero
+}
+
+// ParseRule reads a rule with or without "RRULE:" prefix.
+func ParseRule(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("invalid rule part (%s)", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("interval must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("count must be positive")
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		default:
+			err = fmt.Errorf("unsupported rule part")
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid rule part (%s): %w", part, err)
+		}
+	}
+	switch r.Freq {
+	case FreqWeekly, FreqMonthly, FreqYearly:
+	default:
+		return Rule{}, fmt.Errorf("unsupported frequency (%s)", r.Freq)
+	}
+	return r, nil
+}
+
+func parseUntil(s string) (time.Time, error) {
+	if len(s) >= 8 {
+		s = s[:8]
+	}
+	return time.Parse("20060102", s)
+}
+
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Text describes the rule in german, e.g. "alle 2 Wochen, 6 mal".
+func (r Rule) Text() string {
+	units := map[string][3]string{
+		FreqWeekly:  {"wöchentlich", "Woche", "Wochen"},
+		FreqMonthly: {"monatlich", "Monat", "Monate"},
+		FreqYearly:  {"jährlich", "Jahr", "Jahre"},
+	}[r.Freq]
+	text := units[0]
+	if r.Interval > 1 {
+		text = fmt.Sprintf("alle %d %s", r.Interval, units[2])
+	}
+	if r.Count > 0 {
+		text += fmt.Sprintf(", %d mal", r.Count)
+	}
+	if !r.Until.IsZero() {
+		text += ", bis " + r.Until.Format("02.01.2006")
+	}
+	return text
+}
+
+// nth returns the begin of the nth repetition of first and false if that
+// day does not exist, e.g. the 31st in a month with 30 days.
+func (r Rule) nth(first time.Time, n int) (time.Time, bool) {
+	var t time.Time
+	switch r.Freq {
+	case FreqWeekly:
+		return first.AddDate(0, 0, 7*r.Interval*n), true
+	case FreqMonthly:
+		t = first.AddDate(0, r.Interval*n, 0)
+	case FreqYearly:
+		t = first.AddDate(r.Interval*n, 0, 0)
+	}
+	return t, t.Day() == first.Day()
+}
+
+// lastBegin returns the begin of the last occurrence of a series starting on
+// first, false for open ended series.
+func (r Rule) lastBegin(first time.Time) (time.Time, bool) {
+	if r.Count == 0 && r.Until.IsZero() {
+		return time.Time{}, false
+	}
+	begins := r.begins(first, first, first, first.AddDate(100, 0, 0), nil)
+	if len(begins) == 0 {
+		return first, true
+	}
+	return begins[len(begins)-1], true
+}
+
+// begins returns the begin of every occurrence of a stay from begin to end
+// which overlaps from..to (both inclusive) and is not skipped.
+func (r Rule) begins(begin, end, from, to time.Time, skipped []time.Time) []time.Time {
+	length := end.Sub(begin)
+	found := []time.Time{}
+	count := 0
+	for n := 0; ; n++ {
+		start, ok := r.nth(begin, n)
+		if start.After(to) || (!r.Until.IsZero() && start.After(r.Until)) {
+			break
+		}
+		if !ok {
+			continue
+		}
+		count++
+		if !start.Add(length).Before(from) && !isSkipped(skipped, start) {
+			found = append(found, start)
+		}
+		if r.Count > 0 && count >= r.Count {
+			break
+		}
+	}
+	return found
+}
+
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Begin.Before(entries[j].Begin)
+	})
+}
+
+func isSkipped(skipped []time.Time, day time.Time) bool {
+	for _, s := range skipped {
+		if s.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// spanBegins returns the begins of all occurrences of a possibly recurring
+// stay overlapping from..to. Invalid rules are treated as no recurrence.
+func spanBegins(rule string, begin, end, from, to time.Time, skipped []time.Time) []time.Time {
+	if rule != "" {
+		r, err := ParseRule(rule)
+		if err == nil {
+			return r.begins(begin, end, from, to, skipped)
+		}
+		log.Default().Printf("ignoring recurrence: %s", err)
+	}
+	if begin.After(to) || end.Before(from) {
+		return nil
+	}
+	return []time.Time{begin}
+}
+
+// beginsOn reports whether a series has an occurrence beginning on day which
+// is not skipped yet.
+func beginsOn(rule string, begin, end, day time.Time, skipped []time.Time) bool {
+	return slices.ContainsFunc(spanBegins(rule, begin, end, day, day, skipped), day.Equal)
+}
+
+func (e Entry) IsRecurring() bool {
+	return e.Recurrence != ""
+}
+
+// RecurrenceText describes the recurrence of the entry in german.
+func (e Entry) RecurrenceText() string {
+	r, err := ParseRule(e.Recurrence)
+	if err != nil {
+		return ""
+	}
+	return r.Text()
+}
+
+// occurrences returns copies of the entry for all its occurrences
+// overlapping from..to. The stored cost is the one of the first occurrence,
+// the others are priced for their own dates.
+func (e Entry) occurrences(from, to time.Time, skipped []time.Time) []Entry {
+	found := []Entry{}
+	for _, begin := range spanBegins(e.Recurrence, e.Begin, e.End, from, to, skipped) {
+		occ := e
+		occ.End = begin.Add(e.End.Sub(e.Begin))
+		occ.Begin = begin
+		if !begin.Equal(e.Begin) {
+			occ.Cost = Prices.Cost(occ)
+		}
+		found = append(found, occ)
+	}
+	return found
+}
+
+// TipID identifies the tooltip of the entry in the calendar. Occurrences of a
+// series share the entry's ID, so the begin is part of it.
+func (e Entry) TipID() string {
+	return fmt.Sprintf("tip%d_%s", e.ID, e.Begin.Format("20060102"))
+}
+
+// TipID identifies the tooltip of the block, see Entry.TipID.
+func (b Block) TipID() string {
+	return fmt.Sprintf("block%d_%s", b.ID, b.Begin.Format("20060102"))
+}
+
+func (b Block) occurrences(from, to time.Time, skipped []time.Time) []Block {
+	found := []Block{}
+	for _, begin := range spanBegins(b.Recurrence, b.Begin, b.End, from, to, skipped) {
+		occ := b
+		occ.End = begin.Add(b.End.Sub(b.Begin))
+		occ.Begin = begin
+		found = append(found, occ)
+	}
+	return found
+}
+
+// recurrenceHorizon is the last day on which occurrences of open ended
+// series are checked for conflicts.
+func recurrenceHorizon(now time.Time) time.Time {
+	if BookingPolicy.MaxHorizonDays > 0 {
+		return now.AddDate(0, 0, BookingPolicy.MaxHorizonDays)
+	}
+	return now.AddDate(recurrenceHorizonYears, 0, 0)
+}
+
+// loadSkipped loads the exceptions of the given series from entry_exceptions
+// or block_exceptions.
+func loadSkipped(table, column string, ids []int) (map[int][]time.Time, error) {
+	skipped := map[int][]time.Time{}
+	if len(ids) == 0 {
+		return skipped, nil
+	}
+	in, args := inClause(ids)
+	rows, err := middleware.DB.Query("select "+column+", occurrence from "+table+" where "+column+" in "+in, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var occurrence time.Time
+		err = rows.Scan(&id, &occurrence)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning %s: %w", table, err)
+		}
+		skipped[id] = append(skipped[id], occurrence)
+	}
+	return skipped, rows.Err()
+}
+
+// expandEntries replaces recurring entries by their occurrences overlapping
+// from..to.
+func expandEntries(entries []Entry, from, to time.Time) ([]Entry, error) {
+	ids := []int{}
+	for _, e := range entries {
+		if e.IsRecurring() {
+			ids = append(ids, e.ID)
+		}
+	}
+	skipped, err := loadSkipped("entry_exceptions", "entry_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	expanded := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		expanded = append(expanded, e.occurrences(from, to, skipped[e.ID])...)
+	}
+	sortEntries(expanded)
+	return expanded, nil
+}
+
+func expandBlocks(blocks []Block, from, to time.Time) ([]Block, error) {
+	ids := []int{}
+	for _, b := range blocks {
+		if b.Recurrence != "" {
+			ids = append(ids, b.ID)
+		}
+	}
+	skipped, err := loadSkipped("block_exceptions", "block_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	expanded := make([]Block, 0, len(blocks))
+	for _, b := range blocks {
+		expanded = append(expanded, b.occurrences(from, to, skipped[b.ID])...)
+	}
+	return expanded, nil
+}
+
+// entryOccurrences returns all occurrences of the entry to be checked for
+// conflicts, open ended series up to the recurrence horizon.
+func entryOccurrences(entry Entry) ([]Entry, error) {
+	if !entry.IsRecurring() {
+		return []Entry{entry}, nil
+	}
+	skipped := map[int][]time.Time{}
+	if entry.ID != 0 {
+		var err error
+		skipped, err = loadSkipped("entry_exceptions", "entry_id", []int{entry.ID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	to := recurrenceHorizon(time.Now())
+	if to.Before(entry.End) {
+		to = entry.End
+	}
+	return entry.occurrences(entry.Begin, to, skipped[entry.ID]), nil
+}
+
+// SkipOccurrence removes the occurrence beginning on the given day from a
+// recurring entry.
+func SkipOccurrence(id int, occurrence time.Time, user string) error {
+	entry, err := LoadEntry(id)
+	if err != nil {
+		return err
+	}
+	if !canModify(user, entry) || !entry.IsRecurring() {
+		return fmt.Errorf("user %s may not skip occurrences of entry %d: %w", user, id, ErrForbidden)
+	}
+	skipped, err := loadSkipped("entry_exceptions", "entry_id", []int{id})
+	if err != nil {
+		return err
+	}
+	if !beginsOn(entry.Recurrence, entry.Begin, entry.End, occurrence, skipped[id]) {
+		return fmt.Errorf("entry %d has no occurrence on %s: %w", id, occurrence.Format(time.DateOnly), ErrInvalid)
+	}
+	_, err = middleware.DB.Exec("insert into entry_exceptions (entry_id, occurrence) values (?,?)", id, occurrence)
+	if err != nil {
+		return fmt.Errorf("error skipping occurrence of entry (%d): %w", id, err)
+	}
+	Audit(AuditSkip, "entry", id, nil, map[string]string{"occurrence": occurrence.Format(time.DateOnly)})
+	processWaitlist(entry.ResourceID, occurrence, occurrence.Add(entry.End.Sub(entry.Begin)))
+	return nil
+}
+
+// SkipBlockOccurrence removes the occurrence beginning on the given day from
+// a recurring block.
+func SkipBlockOccurrence(id int, occurrence time.Time) error {
+	blocks, err := queryBlocks("b.id = ?", id)
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return fmt.Errorf("no block found (%d): %w", id, ErrNotFound)
+	}
+	b := blocks[0]
+	skipped, err := loadSkipped("block_exceptions", "block_id", []int{id})
+	if err != nil {
+		return err
+	}
+	if b.Recurrence == "" || !beginsOn(b.Recurrence, b.Begin, b.End, occurrence, skipped[id]) {
+		return fmt.Errorf("block %d has no occurrence on %s: %w", id, occurrence.Format(time.DateOnly), ErrInvalid)
+	}
+	_, err = middleware.DB.Exec("insert into block_exceptions (block_id, occurrence) values (?,?)", id, occurrence)
+	if err != nil {
+		return fmt.Errorf("error skipping occurrence of block (%d): %w", id, err)
+	}
+	Audit(AuditSkip, "block", id, nil, map[string]string{"occurrence": occurrence.Format(time.DateOnly)})
+	processWaitlist(blocks[0].ResourceID, occurrence, occurrence.Add(blocks[0].End.Sub(blocks[0].Begin)))
+	return nil
+}
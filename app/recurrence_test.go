@@ -0,0 +1,131 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rule
+		text string
+	}{
+		{"FREQ=WEEKLY", Rule{Freq: FreqWeekly, Interval: 1}, "wöchentlich"},
+		{"RRULE:FREQ=MONTHLY;INTERVAL=2;COUNT=6", Rule{Freq: FreqMonthly, Interval: 2, Count: 6}, "alle 2 Monate, 6 mal"},
+		{"FREQ=YEARLY;UNTIL=20301231T000000Z", Rule{Freq: FreqYearly, Interval: 1, Until: date(2030, 12, 31)}, "jährlich, bis 31.12.2030"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", tt.in, tt.want, got)
+		}
+		if got.Text() != tt.text {
+			t.Errorf("%s: expected %q, got %q", tt.in, tt.text, got.Text())
+		}
+		again, _ := ParseRule(got.String())
+		if !reflect.DeepEqual(again, got) {
+			t.Errorf("%s: %s does not round trip", tt.in, got.String())
+		}
+	}
+	for _, in := range []string{"", "FREQ=DAILY", "FREQ=WEEKLY;INTERVAL=0", "FREQ=WEEKLY;BYDAY=MO", "FREQ=WEEKLY;COUNT"} {
+		if _, err := ParseRule(in); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
+
+func TestRuleBegins(t *testing.T) {
+	days := func(ts []time.Time) []string {
+		s := []string{}
+		for _, t := range ts {
+			s = append(s, t.Format(time.DateOnly))
+		}
+		return s
+	}
+	tests := []struct {
+		rule       string
+		begin, end time.Time
+		from, to   time.Time
+		skipped    []time.Time
+		want       []string
+	}{
+		// every second week, only the ones overlapping march
+		{"FREQ=WEEKLY;INTERVAL=2", date(2024, 2, 19), date(2024, 2, 20), date(2024, 3, 1), date(2024, 3, 31), nil,
+			[]string{"2024-03-04", "2024-03-18"}},
+		// a stay reaching into the range counts
+		{"FREQ=WEEKLY", date(2024, 2, 26), date(2024, 3, 2), date(2024, 3, 1), date(2024, 3, 10), nil,
+			[]string{"2024-02-26", "2024-03-04"}},
+		// months without a 31st are left out but do not count
+		{"FREQ=MONTHLY;COUNT=3", date(2024, 1, 31), date(2024, 1, 31), date(2024, 1, 1), date(2024, 12, 31), nil,
+			[]string{"2024-01-31", "2024-03-31", "2024-05-31"}},
+		{"FREQ=YEARLY;UNTIL=20270801", date(2024, 8, 1), date(2024, 8, 7), date(2020, 1, 1), date(2030, 12, 31), []time.Time{date(2025, 8, 1)},
+			[]string{"2024-08-01", "2026-08-01", "2027-08-01"}},
+		// no recurrence
+		{"", date(2024, 8, 1), date(2024, 8, 7), date(2024, 8, 5), date(2024, 8, 31), nil,
+			[]string{"2024-08-01"}},
+		{"", date(2024, 8, 1), date(2024, 8, 7), date(2024, 9, 1), date(2024, 9, 30), nil,
+			[]string{}},
+	}
+	for i, tt := range tests {
+		got := days(spanBegins(tt.rule, tt.begin, tt.end, tt.from, tt.to, tt.skipped))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("case %d: expected %v, got %v", i, tt.want, got)
+		}
+	}
+}
+
+func TestBeginsOn(t *testing.T) {
+	rule := "FREQ=WEEKLY;COUNT=3"
+	begin, end := date(2024, 3, 4), date(2024, 3, 6)
+	tests := []struct {
+		day     time.Time
+		skipped []time.Time
+		want    bool
+	}{
+		{date(2024, 3, 4), nil, true},
+		{date(2024, 3, 18), nil, true},
+		{date(2024, 3, 5), nil, false},  // within a stay, not its begin
+		{date(2024, 3, 25), nil, false}, // after the last occurrence
+		{date(2024, 3, 11), []time.Time{date(2024, 3, 11)}, false},
+	}
+	for i, tt := range tests {
+		if got := beginsOn(rule, begin, end, tt.day, tt.skipped); got != tt.want {
+			t.Errorf("case %d: expected %t", i, tt.want)
+		}
+	}
+}
+
+func TestCheckOccurrence(t *testing.T) {
+	house := Resource{ID: 1}
+	rooms := Resource{ID: 2, Capacity: 4}
+	others := []Entry{
+		{ID: 1, Begin: date(2024, 3, 10), End: date(2024, 3, 12), Guests: 3},
+	}
+	blocks := []Block{
+		{ID: 1, Begin: date(2024, 4, 1), End: date(2024, 4, 1), Kind: BlockMaintenance},
+	}
+	tests := []struct {
+		resource Resource
+		entry    Entry
+		want     error
+	}{
+		{house, Entry{Begin: date(2024, 3, 5), End: date(2024, 3, 9)}, nil},
+		{house, Entry{Begin: date(2024, 3, 5), End: date(2024, 3, 10)}, ErrConflict},
+		{rooms, Entry{Begin: date(2024, 3, 11), End: date(2024, 3, 13), Guests: 1}, nil},
+		{rooms, Entry{Begin: date(2024, 3, 11), End: date(2024, 3, 13), Guests: 2}, ErrCapacity},
+		{rooms, Entry{Begin: date(2024, 3, 30), End: date(2024, 4, 2), Guests: 1}, ErrBlocked},
+	}
+	for i, tt := range tests {
+		err := checkOccurrence(tt.resource, tt.entry, blocks, others)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("case %d: expected %v, got %v", i, tt.want, err)
+		}
+	}
+}
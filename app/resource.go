@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"strings"
+)
+
+// Resource is a bookable property or a room within a property.
+type Resource struct {
+	ID       int
+	ParentID int // 0 for properties
+	Name     string
+	Label    string // name including the property for rooms
+	Capacity int    // beds, 0 if the resource is booked exclusively
+}
+
+func (r Resource) IsRoom() bool {
+	return r.ParentID != 0
+}
+
+func LoadResources() ([]Resource, error) {
+	rows, err := middleware.DB.Query("select id, coalesce(parent_id, 0), name, capacity from resources order by coalesce(parent_id, id), parent_id is not null, name")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching resources: %w", err)
+	}
+	defer rows.Close()
+
+	resources := []Resource{}
+	for rows.Next() {
+		var r Resource
+		err = rows.Scan(&r.ID, &r.ParentID, &r.Name, &r.Capacity)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning resource: %w", err)
+		}
+		resources = append(resources, r)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching resources: %w", rows.Err())
+	}
+	return labelResources(resources), nil
+}
+
+// ResolveResource loads all resources and returns the one with the given id
+// or the first one if there is no such resource.
+func ResolveResource(id int) (Resource, []Resource, error) {
+	resources, err := LoadResources()
+	if err != nil {
+		return Resource{}, nil, err
+	}
+	if len(resources) == 0 {
+		return Resource{}, nil, fmt.Errorf("no resources defined: %w", ErrNotFound)
+	}
+	for _, r := range resources {
+		if r.ID == id {
+			return r, resources, nil
+		}
+	}
+	return resources[0], resources, nil
+}
+
+func labelResources(resources []Resource) []Resource {
+	names := make(map[int]string, len(resources))
+	for _, r := range resources {
+		names[r.ID] = r.Name
+	}
+	for i, r := range resources {
+		resources[i].Label = r.Name
+		if r.IsRoom() {
+			resources[i].Label = fmt.Sprintf("%s / %s", names[r.ParentID], r.Name)
+		}
+	}
+	return resources
+}
+
+// conflictScope returns the ids of all resources whose bookings collide with
+// a booking of the given resource: the resource itself, the property it
+// belongs to and all rooms within it.
+func conflictScope(resources []Resource, id int) []int {
+	scope := []int{id}
+	for _, r := range resources {
+		if r.ID == id && r.IsRoom() {
+			scope = append(scope, r.ParentID)
+		}
+		if r.ParentID == id {
+			scope = append(scope, r.ID)
+		}
+	}
+	return scope
+}
+
+func loadConflictScope(id int) ([]int, error) {
+	resources, err := LoadResources()
+	if err != nil {
+		return nil, err
+	}
+	return conflictScope(resources, id), nil
+}
+
+// inClause returns a placeholder list like "(?,?,?)" and the matching args.
+func inClause(ids []int) (string, []any) {
+	args := make([]any, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + ")", args
+}
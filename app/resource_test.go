@@ -0,0 +1,39 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConflictScope(t *testing.T) {
+	resources := labelResources([]Resource{
+		{ID: 1, Name: "Chalet"},
+		{ID: 3, ParentID: 1, Name: "Zimmer 1"},
+		{ID: 4, ParentID: 1, Name: "Zimmer 2"},
+		{ID: 2, Name: "Stöckli"},
+	})
+	tests := []struct {
+		id    int
+		scope []int
+	}{
+		{1, []int{1, 3, 4}},
+		{3, []int{3, 1}},
+		{4, []int{4, 1}},
+		{2, []int{2}},
+	}
+	for _, tt := range tests {
+		if got := conflictScope(resources, tt.id); !reflect.DeepEqual(got, tt.scope) {
+			t.Errorf("resource %d: expected scope %v, got %v", tt.id, tt.scope, got)
+		}
+	}
+	if resources[1].Label != "Chalet / Zimmer 1" || resources[3].Label != "Stöckli" {
+		t.Errorf("unexpected labels: %+v", resources)
+	}
+}
+
+func TestInClause(t *testing.T) {
+	in, args := inClause([]int{1, 2, 3})
+	if in != "(?,?,?)" || len(args) != 3 {
+		t.Errorf("unexpected clause %s %v", in, args)
+	}
+}
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Rotation assigns recurring periods to households, shifting the assignment
+// by one household every year.
+type Rotation struct {
+	ID         int
+	Name       string
+	ResourceID int
+	StartYear  int
+	Households []Household
+	Periods    []Period
+}
+
+type Assignment struct {
+	Period    Period
+	Household Household
+	Begin     time.Time
+	End       time.Time
+}
+
+type RotationYear struct {
+	Year         int
+	Assignments  []Assignment
+	Materialized bool
+}
+
+// Assign returns the assignments of the given year. In the start year the
+// first period goes to the first household, the second period to the second
+// household and so on; every following year everybody moves one period
+// further.
+func (r Rotation) Assign(year int) []Assignment {
+	n := len(r.Households)
+	if n == 0 {
+		return nil
+	}
+	shift := ((year-r.StartYear)%n + n) % n
+	assignments := make([]Assignment, 0, len(r.Periods))
+	for i, p := range r.Periods {
+		begin, end := p.Occurrence(year)
+		assignments = append(assignments, Assignment{
+			Period:    p,
+			Household: r.Households[(i+n-shift)%n],
+			Begin:     begin,
+			End:       end,
+		})
+	}
+	return assignments
+}
+
+// Schedule returns the assignments of the given number of years starting at
+// from.
+func (r Rotation) Schedule(from, years int) []RotationYear {
+	schedule := make([]RotationYear, 0, years)
+	for year := from; year < from+years; year++ {
+		schedule = append(schedule, RotationYear{
+			Year:        year,
+			Assignments: r.Assign(year),
+		})
+	}
+	return schedule
+}
+
+func (r Rotation) HouseholdList() string {
+	names := make([]string, 0, len(r.Households))
+	for _, h := range r.Households {
+		names = append(names, h.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// householdIDs is the stored form of the households.
+func (r Rotation) householdIDs() string {
+	ids := make([]string, 0, len(r.Households))
+	for _, h := range r.Households {
+		ids = append(ids, strconv.Itoa(h.ID))
+	}
+	return strings.Join(ids, ",")
+}
+
+func (r Rotation) PeriodList() string {
+	periods := make([]string, 0, len(r.Periods))
+	for _, p := range r.Periods {
+		periods = append(periods, fmt.Sprintf("%s:%s:%s", p.Label, p.Start, p.End))
+	}
+	return strings.Join(periods, ", ")
+}
+
+// ParseHouseholds reads a comma separated list of household names in the
+// order of the rotation. Unknown or repeated names wrap ErrInvalid.
+func ParseHouseholds(s string, households []Household) ([]Household, error) {
+	parsed := []Household{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		i := slices.IndexFunc(households, func(h Household) bool { return strings.EqualFold(h.Name, name) })
+		if i < 0 {
+			return nil, fmt.Errorf("no household %q: %w", name, ErrInvalid)
+		}
+		if slices.ContainsFunc(parsed, func(h Household) bool { return h.ID == households[i].ID }) {
+			return nil, fmt.Errorf("household %q is named twice: %w", name, ErrInvalid)
+		}
+		parsed = append(parsed, households[i])
+	}
+	return parsed, nil
+}
+
+func SaveRotation(r Rotation) error {
+	if len(r.Households) == 0 || len(r.Periods) == 0 {
+		return fmt.Errorf("a rotation needs households and periods: %w", ErrInvalid)
+	}
+	_, err := middleware.DB.Exec("insert into rotations (name, resource_id, start_year, participants, periods) values (?,?,?,?,?)", r.Name, r.ResourceID, r.StartYear, r.householdIDs(), r.PeriodList())
+	if err != nil {
+		return fmt.Errorf("error inserting rotation: %w", err)
+	}
+	Audit(AuditCreate, "rotation", 0, nil, r)
+	return nil
+}
+
+func LoadRotations() ([]Rotation, error) {
+	households, err := LoadHouseholds()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]Household, len(households))
+	for _, h := range households {
+		byID[h.ID] = h
+	}
+	rows, err := middleware.DB.Query("select id, name, resource_id, start_year, participants, periods from rotations order by name")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rotations: %w", err)
+	}
+	defer rows.Close()
+
+	rotations := []Rotation{}
+	for rows.Next() {
+		var r Rotation
+		var participants, periods string
+		err = rows.Scan(&r.ID, &r.Name, &r.ResourceID, &r.StartYear, &participants, &periods)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning rotation: %w", err)
+		}
+		for _, id := range strings.Split(participants, ",") {
+			householdID, _ := strconv.Atoi(strings.TrimSpace(id))
+			h, ok := byID[householdID]
+			if !ok {
+				log.Default().Printf("ignoring unknown household %q of rotation %d", id, r.ID)
+				continue
+			}
+			r.Households = append(r.Households, h)
+		}
+		r.Periods, err = ParsePeriods(periods)
+		if err != nil {
+			log.Default().Printf("ignoring invalid periods of rotation %d: %s", r.ID, err)
+		}
+		rotations = append(rotations, r)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching rotations: %w", rows.Err())
+	}
+	return rotations, nil
+}
+
+func LoadRotation(id int) (Rotation, error) {
+	rotations, err := LoadRotations()
+	if err != nil {
+		return Rotation{}, err
+	}
+	for _, r := range rotations {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return Rotation{}, fmt.Errorf("no rotation found (%d): %w", id, ErrNotFound)
+}
+
+// PreviewRotation returns the schedule and marks the years already turned
+// into entries.
+func PreviewRotation(r Rotation, from, years int) ([]RotationYear, error) {
+	schedule := r.Schedule(from, years)
+	rows, err := middleware.DB.Query("select year from rotation_years where rotation_id = ?", r.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching materialized years: %w", err)
+	}
+	defer rows.Close()
+	materialized := map[int]bool{}
+	for rows.Next() {
+		var year int
+		if err := rows.Scan(&year); err != nil {
+			return nil, fmt.Errorf("error scanning materialized year: %w", err)
+		}
+		materialized[year] = true
+	}
+	for i := range schedule {
+		schedule[i].Materialized = materialized[schedule[i].Year]
+	}
+	return schedule, nil
+}
+
+// MaterializeRotation creates confirmed entries for all assignments of the
+// year, booked for the household by its first member. Assignments colliding
+// with existing entries or of households without members are skipped and
+// returned as problems. The year is marked as materialized together with its entries,
+// and only if at least one of them could be created.
+func MaterializeRotation(r Rotation, year int) ([]string, error) {
+	var done int
+	err := middleware.DB.QueryRow("select count(*) from rotation_years where rotation_id = ? and year = ?", r.ID, year).Scan(&done)
+	if err != nil {
+		return nil, fmt.Errorf("error checking materialized years of rotation %d: %w", r.ID, err)
+	}
+	if done > 0 {
+		return nil, fmt.Errorf("rotation %d was already materialized for %d: %w", r.ID, year, ErrConflict)
+	}
+	resource, resources, err := ResolveResource(r.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	problems := []string{}
+	created := []Entry{}
+	assignments := []Assignment{}
+	for _, a := range r.Assign(year) {
+		if len(a.Household.Members) == 0 {
+			problems = append(problems, fmt.Sprintf("%s (%s) konnte nicht eingetragen werden, der Haushalt hat keine Mitglieder.", a.Period.Label, a.Household.Name))
+			continue
+		}
+		entry := Entry{
+			User:        a.Household.Members[0],
+			HouseholdID: a.Household.ID,
+			ResourceID:  resource.ID,
+			Begin:       a.Begin,
+			End:         a.End,
+			Guests:      1,
+			Status:      StatusConfirmed,
+			Bemerkungen: fmt.Sprintf("%s: %s", r.Name, a.Period.Label),
+		}
+		err = checkAvailability(resources, resource, entry, created...)
+		if errors.Is(err, ErrConflict) {
+			log.Default().Printf("error materializing %s for %s: %s", a.Period.Label, a.Household.Name, err)
+			problems = append(problems, fmt.Sprintf("%s (%s) konnte nicht eingetragen werden.", a.Period.Label, a.Household.Name))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry, err = storeEntry(tx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("error materializing %s for %s: %w", a.Period.Label, a.Household.Name, err)
+		}
+		created = append(created, entry)
+		assignments = append(assignments, a)
+	}
+	if len(created) == 0 {
+		return problems, nil
+	}
+
+	_, err = tx.Exec("insert into rotation_years (rotation_id, year, materialized_at) values (?,?,?)", r.ID, year, time.Now())
+	if isDuplicateKey(err) {
+		return nil, fmt.Errorf("rotation %d was already materialized for %d: %w", r.ID, year, ErrConflict)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error marking rotation %d as materialized for %d: %w", r.ID, year, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error materializing rotation %d for %d: %w", r.ID, year, err)
+	}
+
+	Audit(AuditMaterialize, "rotation", r.ID, nil, map[string]int{"year": year})
+	for i, e := range created {
+		Audit(AuditCreate, "entry", e.ID, nil, e)
+		a := assignments[i]
+		for _, member := range a.Household.Members {
+			Notify(member, fmt.Sprintf("%s: %s vom %s bis %s ist für %s reserviert.", r.Name, a.Period.Label, a.Begin.Format("02.01.2006"), a.End.Format("02.01.2006"), a.Household.Name))
+		}
+	}
+	return problems, nil
+}
+
+// isDuplicateKey reports whether err is MySQL's error for a duplicate
+// primary or unique key.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRotationSchedule(t *testing.T) {
+	july1, _ := ParsePeriod("Juli 1", "07-01", "07-14")
+	july2, _ := ParsePeriod("Juli 2", "07-15", "07-28")
+	august, _ := ParsePeriod("August", "08-01", "08-14")
+	r := Rotation{
+		StartYear:  2024,
+		Households: []Household{{ID: 1, Name: "anna"}, {ID: 2, Name: "beat"}, {ID: 3, Name: "carla"}},
+		Periods:    []Period{july1, july2, august},
+	}
+	if ids := r.householdIDs(); ids != "1,2,3" {
+		t.Errorf("unexpected ids %s", ids)
+	}
+
+	participants := func(year int) []string {
+		names := []string{}
+		for _, a := range r.Assign(year) {
+			names = append(names, a.Household.Name)
+		}
+		return names
+	}
+	tests := map[int][]string{
+		2023: {"beat", "carla", "anna"},
+		2024: {"anna", "beat", "carla"},
+		2025: {"carla", "anna", "beat"},
+		2026: {"beat", "carla", "anna"},
+		2027: {"anna", "beat", "carla"},
+	}
+	for year, want := range tests {
+		if got := participants(year); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: expected %v, got %v", year, want, got)
+		}
+	}
+
+	schedule := r.Schedule(2025, 3)
+	if len(schedule) != 3 || schedule[0].Year != 2025 || schedule[2].Year != 2027 {
+		t.Fatalf("unexpected schedule %+v", schedule)
+	}
+	if a := schedule[0].Assignments[2]; !a.Begin.Equal(date(2025, 8, 1)) || !a.End.Equal(date(2025, 8, 14)) {
+		t.Errorf("unexpected dates %s - %s", a.Begin, a.End)
+	}
+}
+
+func TestParseHouseholds(t *testing.T) {
+	households := []Household{{ID: 1, Name: "Huber"}, {ID: 2, Name: "Muster"}, {ID: 3, Name: "Keller"}}
+	got, err := ParseHouseholds(" muster, Huber,,Keller ", households)
+	if err != nil || !reflect.DeepEqual(got, []Household{households[1], households[0], households[2]}) {
+		t.Errorf("unexpected households %v (%v)", got, err)
+	}
+	for _, in := range []string{"Huber, Meier", "Huber, huber"} {
+		if _, err := ParseHouseholds(in, households); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: expected invalid, got %v", in, err)
+		}
+	}
+}
+
+func TestIsDuplicateKey(t *testing.T) {
+	if !isDuplicateKey(fmt.Errorf("insert: %w", &mysql.MySQLError{Number: 1062})) {
+		t.Error("expected wrapped duplicate key error to be detected")
+	}
+	if isDuplicateKey(&mysql.MySQLError{Number: 1452}) || isDuplicateKey(errors.New("connection lost")) || isDuplicateKey(nil) {
+		t.Error("expected other errors not to be duplicate keys")
+	}
+}
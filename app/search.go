@@ -0,0 +1,64 @@
+package app
+
+import (
+	"html"
+	"html/template"
+	"strings"
+)
+
+// SearchEntries finds entries matching the filter, newest first, so the last
+// occurrence of something is on top.
+func SearchEntries(filter EntryFilter) (EntryPage, error) {
+	return queryEntries(filter, "e.begin desc")
+}
+
+func searchTerms(text string) []string {
+	return strings.Fields(text)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Highlight escapes text and wraps every case insensitive occurrence of the
+// words in query into a mark element.
+func Highlight(text, query string) template.HTML {
+	terms := searchTerms(strings.ToLower(query))
+	if len(terms) == 0 {
+		return template.HTML(html.EscapeString(text))
+	}
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// lower casing changed byte offsets, better not highlight at all
+		return template.HTML(html.EscapeString(text))
+	}
+	// marked[i] is true for every byte of text which is part of a match
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		for start := 0; ; {
+			idx := strings.Index(lower[start:], term)
+			if idx < 0 {
+				break
+			}
+			for i := start + idx; i < start+idx+len(term); i++ {
+				marked[i] = true
+			}
+			start += idx + len(term)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		j := i
+		for j < len(text) && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			b.WriteString("<mark>" + html.EscapeString(text[i:j]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(text[i:j]))
+		}
+		i = j
+	}
+	return template.HTML(b.String())
+}
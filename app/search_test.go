@@ -0,0 +1,32 @@
+package app
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text, query string
+		want        template.HTML
+	}{
+		{"Kaminfeger kommt", "", "Kaminfeger kommt"},
+		{"Kaminfeger kommt", "kaminfeger", "<mark>Kaminfeger</mark> kommt"},
+		{"Kaminfeger kommt", "kamin KOMMT", "<mark>Kamin</mark>feger <mark>kommt</mark>"},
+		{"Anna & Peter", "anna", "<mark>Anna</mark> &amp; Peter"},
+		{"<b>Ski</b>", "ski", "&lt;b&gt;<mark>Ski</mark>&lt;/b&gt;"},
+		{"aaaa", "aa", "<mark>aaaa</mark>"},
+		{"Grüsse", "grü", "<mark>Grü</mark>sse"},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text, tt.query); got != tt.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50%_a\b`); got != `50\%\_a\\b` {
+		t.Errorf("unexpected escaping: %s", got)
+	}
+}
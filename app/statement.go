@@ -0,0 +1,306 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// RoleTreasurer may record payments and see the statements of everybody.
+const RoleTreasurer = "treasurer"
+
+func (u User) IsTreasurer() bool {
+	return u.Role == RoleTreasurer || u.IsAdmin()
+}
+
+// Account is who pays for an entry: its household, or the user for entries
+// booked without household.
+type Account struct {
+	User        string
+	HouseholdID int
+	Name        string
+}
+
+func accountOf(entry Entry) Account {
+	if entry.HouseholdID != 0 {
+		return Account{HouseholdID: entry.HouseholdID, Name: entry.Household}
+	}
+	return Account{User: entry.User, Name: entry.User}
+}
+
+// Query returns the parameters identifying the account in links.
+func (a Account) Query() string {
+	if a.HouseholdID != 0 {
+		return "household=" + strconv.Itoa(a.HouseholdID)
+	}
+	return "user=" + url.QueryEscape(a.User)
+}
+
+func (a Account) same(b Account) bool {
+	return a.HouseholdID == b.HouseholdID && (a.HouseholdID != 0 || a.User == b.User)
+}
+
+// Payment is money received from an account for the statement of a year.
+type Payment struct {
+	ID         int
+	Year       int
+	Account    Account
+	Amount     Money
+	PaidOn     time.Time
+	Note       string
+	RecordedBy string
+}
+
+// Statement sums up what an account owes for the confirmed stays beginning
+// in the year and what it has paid.
+type Statement struct {
+	Year     int
+	Account  Account
+	Entries  []Entry
+	Nights   int
+	Amount   Money
+	Payments []Payment
+	Paid     Money
+}
+
+// Nights returns the number of nights of the stay.
+func (e Entry) Nights() int {
+	return nightsWhere(e, func(time.Time) bool { return true })
+}
+
+func (s Statement) Open() Money {
+	return s.Amount - s.Paid
+}
+
+func (s Statement) IsPaid() bool {
+	return s.Open() <= 0
+}
+
+// buildStatements groups the entries and payments of the year by account.
+// Accounts without stays but with payments get a statement as well, names of
+// households only having payments are taken from names.
+func buildStatements(year int, entries []Entry, payments []Payment, names map[int]string) []Statement {
+	statements := []Statement{}
+	find := func(a Account) *Statement {
+		for i := range statements {
+			if statements[i].Account.same(a) {
+				return &statements[i]
+			}
+		}
+		if a.HouseholdID != 0 && a.Name == "" {
+			a.Name = names[a.HouseholdID]
+		}
+		statements = append(statements, Statement{Year: year, Account: a})
+		return &statements[len(statements)-1]
+	}
+	for _, e := range entries {
+		if e.Begin.Year() != year || e.Status != StatusConfirmed {
+			continue
+		}
+		s := find(accountOf(e))
+		s.Entries = append(s.Entries, e)
+		s.Nights += e.Nights()
+		s.Amount += e.Cost
+	}
+	for _, p := range payments {
+		if p.Year != year {
+			continue
+		}
+		s := find(p.Account)
+		s.Payments = append(s.Payments, p)
+		s.Paid += p.Amount
+	}
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Account.Name < statements[j].Account.Name
+	})
+	return statements
+}
+
+// LoadStatements returns the statements of all accounts for the year.
+func LoadStatements(year int) ([]Statement, error) {
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)
+	where, args := EntryFilter{From: from, To: to, Statuses: []string{StatusConfirmed}}.where()
+	rows, err := middleware.DB.Query("select "+entryColumns+" from "+entryTables+" where "+where+" order by e.begin", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching entries of %d: %w", year, err)
+	}
+	defer rows.Close()
+	entries := []Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching entries of %d: %w", year, rows.Err())
+	}
+	entries, err = expandEntries(entries, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := loadPayments(year)
+	if err != nil {
+		return nil, err
+	}
+	households, err := LoadHouseholds()
+	if err != nil {
+		return nil, err
+	}
+	names := map[int]string{}
+	for _, h := range households {
+		names[h.ID] = h.Name
+	}
+	return buildStatements(year, entries, payments, names), nil
+}
+
+// LoadStatement returns the statement of one account, empty if it had no
+// stays nor payments in the year.
+func LoadStatement(year int, account Account) (Statement, error) {
+	statements, err := LoadStatements(year)
+	if err != nil {
+		return Statement{}, err
+	}
+	for _, s := range statements {
+		if s.Account.same(account) {
+			return s, nil
+		}
+	}
+	if account.Name == "" {
+		account.Name = account.User
+	}
+	return Statement{Year: year, Account: account}, nil
+}
+
+func loadPayments(year int) ([]Payment, error) {
+	rows, err := middleware.DB.Query("select p.id, p.year, coalesce(p.user, ''), coalesce(p.household_id, 0), coalesce(h.name, ''), p.amount, p.paid_on, p.note, p.recorded_by from payments p left join households h on h.id = p.household_id where p.year = ? order by p.paid_on, p.id", year)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching payments of %d: %w", year, err)
+	}
+	defer rows.Close()
+	payments := []Payment{}
+	for rows.Next() {
+		var p Payment
+		err = rows.Scan(&p.ID, &p.Year, &p.Account.User, &p.Account.HouseholdID, &p.Account.Name, &p.Amount, &p.PaidOn, &p.Note, &p.RecordedBy)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning payment: %w", err)
+		}
+		if p.Account.HouseholdID != 0 {
+			p.Account.User = ""
+		} else {
+			p.Account.Name = p.Account.User
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+// RecordPayment books a payment against the statement of the account. Only
+// treasurers and admins may record payments.
+func RecordPayment(p Payment, user string) error {
+	actor, err := LoadUser(user)
+	if err != nil {
+		return err
+	}
+	if !actor.IsTreasurer() {
+		Audit(AuditDenied, "payment", 0, nil, p)
+		return fmt.Errorf("user %s may not record payments: %w", user, ErrForbidden)
+	}
+	if p.Amount == 0 || (p.Account.User == "" && p.Account.HouseholdID == 0) {
+		return fmt.Errorf("invalid payment %+v: %w", p, ErrInvalid)
+	}
+	var account, household any
+	if p.Account.HouseholdID != 0 {
+		household = p.Account.HouseholdID
+	} else {
+		account = p.Account.User
+	}
+	p.RecordedBy = user
+	_, err = middleware.DB.Exec("insert into payments (year, user, household_id, amount, paid_on, note, recorded_by) values (?,?,?,?,?,?,?)",
+		p.Year, account, household, p.Amount, p.PaidOn, p.Note, p.RecordedBy)
+	if err != nil {
+		return fmt.Errorf("error inserting payment: %w", err)
+	}
+	Audit(AuditCreate, "payment", 0, nil, p)
+	return nil
+}
+
+// DeletePayment removes a payment recorded by mistake.
+func DeletePayment(id int, user string) error {
+	actor, err := LoadUser(user)
+	if err != nil {
+		return err
+	}
+	if !actor.IsTreasurer() {
+		Audit(AuditDenied, "payment", id, nil, nil)
+		return fmt.Errorf("user %s may not delete payment %d: %w", user, id, ErrForbidden)
+	}
+	_, err = middleware.DB.Exec("delete from payments where id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting payment (%d): %w", id, err)
+	}
+	Audit(AuditDelete, "payment", id, nil, nil)
+	return nil
+}
+
+// StatementPDF renders the statement as a one or more page PDF.
+func StatementPDF(s Statement, title string) []byte {
+	const (
+		left  = pdfMargin
+		right = pdfPageWidth - pdfMargin
+	)
+	d := &pdfDoc{}
+	d.Line(16, true, pdfCell{X: left, Text: title})
+	d.Line(13, true, pdfCell{X: left, Text: fmt.Sprintf("Jahresabrechnung %d", s.Year)})
+	d.Line(10, false, pdfCell{X: left, Text: "für " + s.Account.Name})
+	d.Skip(10)
+
+	d.Line(10, true,
+		pdfCell{X: left, Text: "Anreise"},
+		pdfCell{X: left + 80, Text: "Abreise"},
+		pdfCell{X: left + 160, Text: "Objekt"},
+		pdfCell{X: left + 300, Text: "Nächte", Right: true},
+		pdfCell{X: left + 360, Text: "Personen", Right: true},
+		pdfCell{X: right, Text: "Betrag", Right: true})
+	for _, e := range s.Entries {
+		d.Line(10, false,
+			pdfCell{X: left, Text: e.Begin.Format("02.01.2006")},
+			pdfCell{X: left + 80, Text: e.End.Format("02.01.2006")},
+			pdfCell{X: left + 160, Text: e.ResourceName},
+			pdfCell{X: left + 300, Text: strconv.Itoa(e.Nights()), Right: true},
+			pdfCell{X: left + 360, Text: strconv.Itoa(e.Guests), Right: true},
+			pdfCell{X: right, Text: e.Cost.String(), Right: true})
+	}
+	d.Line(10, true,
+		pdfCell{X: left, Text: "Total"},
+		pdfCell{X: left + 300, Text: strconv.Itoa(s.Nights), Right: true},
+		pdfCell{X: right, Text: s.Amount.String(), Right: true})
+	d.Skip(10)
+
+	d.Line(10, true, pdfCell{X: left, Text: "Zahlungen"})
+	for _, p := range s.Payments {
+		d.Line(10, false,
+			pdfCell{X: left, Text: p.PaidOn.Format("02.01.2006")},
+			pdfCell{X: left + 80, Text: p.Note},
+			pdfCell{X: right, Text: p.Amount.String(), Right: true})
+	}
+	d.Line(10, true,
+		pdfCell{X: left, Text: "Bezahlt"},
+		pdfCell{X: right, Text: s.Paid.String(), Right: true})
+	d.Line(10, true,
+		pdfCell{X: left, Text: "Offen"},
+		pdfCell{X: right, Text: s.Open().String(), Right: true})
+	d.Skip(10)
+	status := "offen"
+	if s.IsPaid() {
+		status = "bezahlt"
+	}
+	d.Line(10, false, pdfCell{X: left, Text: "Status: " + status})
+	return d.Bytes()
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBuildStatements(t *testing.T) {
+	entries := []Entry{
+		{User: "anna", HouseholdID: 1, Household: "Meier", Begin: date(2024, 3, 1), End: date(2024, 3, 4), Cost: 9000, Status: StatusConfirmed},
+		{User: "ben", HouseholdID: 1, Household: "Meier", Begin: date(2024, 7, 1), End: date(2024, 7, 3), Cost: 6000, Status: StatusConfirmed},
+		{User: "carl", Begin: date(2024, 5, 1), End: date(2024, 5, 2), Cost: 3000, Status: StatusConfirmed},
+		{User: "carl", Begin: date(2024, 6, 1), End: date(2024, 6, 2), Cost: 3000, Status: StatusRequested},
+		{User: "carl", Begin: date(2023, 12, 30), End: date(2024, 1, 2), Cost: 9000, Status: StatusConfirmed},
+	}
+	payments := []Payment{
+		{Year: 2024, Account: Account{HouseholdID: 1, Name: "Meier"}, Amount: 15000},
+		{Year: 2024, Account: Account{User: "carl", Name: "carl"}, Amount: 1000},
+		{Year: 2024, Account: Account{HouseholdID: 2}, Amount: 500},
+		{Year: 2023, Account: Account{User: "carl", Name: "carl"}, Amount: 9000},
+	}
+	statements := buildStatements(2024, entries, payments, map[int]string{2: "Huber"})
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %+v", statements)
+	}
+
+	huber, meier, carl := statements[0], statements[1], statements[2]
+	if huber.Account.Name != "Huber" || huber.Amount != 0 || huber.Paid != 500 || !huber.IsPaid() {
+		t.Errorf("unexpected statement of Huber %+v", huber)
+	}
+	if meier.Nights != 5 || meier.Amount != 15000 || meier.Open() != 0 || !meier.IsPaid() || len(meier.Entries) != 2 {
+		t.Errorf("unexpected statement of Meier %+v", meier)
+	}
+	if carl.Nights != 1 || carl.Amount != 3000 || carl.Open() != 2000 || carl.IsPaid() {
+		t.Errorf("unexpected statement of carl %+v", carl)
+	}
+}
+
+func TestAccountQuery(t *testing.T) {
+	if q := (Account{HouseholdID: 3, Name: "Meier"}).Query(); q != "household=3" {
+		t.Errorf("unexpected %s", q)
+	}
+	if q := (Account{User: "anna"}).Query(); q != "user=anna" {
+		t.Errorf("unexpected %s", q)
+	}
+}
+
+func TestStatementPDF(t *testing.T) {
+	s := Statement{
+		Year:    2024,
+		Account: Account{HouseholdID: 1, Name: "Müller (Zürich)"},
+		Entries: []Entry{{ResourceName: "Haus", Begin: date(2024, 3, 1), End: date(2024, 3, 4), Guests: 2, Cost: 9000}},
+		Nights:  3,
+		Amount:  9000,
+	}
+	pdf := StatementPDF(s, "Ferienhaus")
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a pdf: %q", pdf)
+	}
+	if !bytes.Contains(pdf, []byte("(f\xfcr M\xfcller \\(Z\xfcrich\\))")) {
+		t.Errorf("name not encoded: %q", pdf)
+	}
+
+	// every offset in the cross reference table has to point to its object
+	xref := bytes.LastIndex(pdf, []byte("\nxref\n")) + 1
+	var count int
+	fmt.Sscanf(string(pdf[xref:]), "xref\n0 %d", &count)
+	lines := bytes.Split(pdf[xref:], []byte("\n"))[3 : 3+count-1]
+	for i, line := range lines {
+		var offset int
+		fmt.Sscanf(string(line), "%d", &offset)
+		want := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("offset %d of object %d points to %q", offset, i+1, pdf[offset:offset+10])
+		}
+	}
+}
+
+func TestPDFPages(t *testing.T) {
+	d := &pdfDoc{}
+	for i := 0; i < 100; i++ {
+		d.Line(10, false, pdfCell{X: pdfMargin, Text: "Zeile"})
+	}
+	if len(d.pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(d.pages))
+	}
+	if w := pdfTextWidth("123.45", 10); w != 5*5.56+2.78 {
+		t.Errorf("unexpected width %f", w)
+	}
+}
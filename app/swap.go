@@ -0,0 +1,310 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	SwapRequested = "requested"
+	SwapAccepted  = "accepted"
+	SwapDeclined  = "declined"
+	SwapWithdrawn = "withdrawn"
+	SwapObsolete  = "obsolete" // one of the entries changed hands otherwise
+)
+
+var GermanSwapStatus = map[string]string{
+	SwapRequested: "offen",
+	SwapAccepted:  "angenommen",
+	SwapDeclined:  "abgelehnt",
+	SwapWithdrawn: "zurückgezogen",
+	SwapObsolete:  "hinfällig",
+}
+
+// Swap proposes to exchange Entry against Counter or, without Counter, to
+// hand Entry over to the recipient.
+type Swap struct {
+	ID        int
+	Entry     Entry
+	Counter   Entry // ID is 0 for a transfer
+	Proposer  string
+	Recipient string
+	Message   string
+	Status    string
+	CreatedAt time.Time
+	DecidedAt time.Time
+}
+
+func (s Swap) IsTransfer() bool {
+	return s.Counter.ID == 0
+}
+
+func (s Swap) IsOpen() bool {
+	return s.Status == SwapRequested
+}
+
+func (s Swap) StatusName() string {
+	return GermanSwapStatus[s.Status]
+}
+
+func (s Swap) describe() string {
+	what := fmt.Sprintf("%s (%s - %s)", s.Entry.ResourceName, s.Entry.Begin.Format("02.01.2006"), s.Entry.End.Format("02.01.2006"))
+	if s.IsTransfer() {
+		return fmt.Sprintf("%s möchte dir die Reservation %s abgeben", s.Proposer, what)
+	}
+	return fmt.Sprintf("%s möchte die Reservation %s gegen deine Reservation %s (%s - %s) tauschen", s.Proposer, what,
+		s.Counter.ResourceName, s.Counter.Begin.Format("02.01.2006"), s.Counter.End.Format("02.01.2006"))
+}
+
+// checkSwap verifies that the proposer may give away the entry and that the
+// counter entry belongs to the recipient.
+func checkSwap(s Swap, proposer User) error {
+	if !s.Entry.IsActive() || !ownedBy(s.Entry, proposer.Name, proposer.Household) {
+		return fmt.Errorf("%s may not give away entry %d: %w", proposer.Name, s.Entry.ID, ErrForbidden)
+	}
+	if s.Recipient == "" || strings.EqualFold(s.Recipient, s.Entry.User) || strings.EqualFold(s.Recipient, proposer.Name) {
+		return fmt.Errorf("invalid recipient (%s) for entry %d: %w", s.Recipient, s.Entry.ID, ErrConflict)
+	}
+	if s.IsTransfer() {
+		return nil
+	}
+	if s.Counter.ID == s.Entry.ID || !s.Counter.IsActive() || s.Counter.User != s.Recipient {
+		return fmt.Errorf("entry %d can not be swapped against entry %d of %s: %w", s.Entry.ID, s.Counter.ID, s.Recipient, ErrConflict)
+	}
+	return nil
+}
+
+// ProposeSwap stores the proposal and notifies the recipient, who is the
+// owner of the counter entry for exchanges.
+func ProposeSwap(entryID, counterID int, recipient, message, user string) error {
+	proposer, err := LoadUser(user)
+	if err != nil {
+		return err
+	}
+	s := Swap{Proposer: proposer.Name, Recipient: recipient, Message: message}
+	s.Entry, err = LoadEntry(entryID)
+	if err != nil {
+		return err
+	}
+	var counter any
+	if counterID != 0 {
+		s.Counter, err = LoadEntry(counterID)
+		if err != nil {
+			return err
+		}
+		s.Recipient = s.Counter.User
+		counter = counterID
+	}
+	if _, err := LoadUser(s.Recipient); err != nil {
+		return err
+	}
+	err = checkSwap(s, proposer)
+	if err != nil {
+		return err
+	}
+	_, err = middleware.DB.Exec("insert into swaps (entry_id, counter_entry_id, proposer, recipient, message, status, created_at) values (?,?,?,?,?,?,?)",
+		s.Entry.ID, counter, s.Proposer, s.Recipient, s.Message, SwapRequested, time.Now())
+	if err != nil {
+		return fmt.Errorf("error inserting swap: %w", err)
+	}
+	Audit(AuditCreate, "swap", 0, nil, s)
+	text := s.describe() + "."
+	if s.Message != "" {
+		text += " " + s.Message
+	}
+	Notify(s.Recipient, text)
+	return nil
+}
+
+// AcceptSwap switches the owners of the entries in one transaction. Other
+// open proposals for the same entries become obsolete.
+func AcceptSwap(id int, user string) error {
+	s, err := LoadSwap(id)
+	if err != nil {
+		return err
+	}
+	if !s.IsOpen() || !strings.EqualFold(s.Recipient, user) {
+		return fmt.Errorf("user %s may not accept swap %d: %w", user, id, ErrForbidden)
+	}
+	proposer, err := LoadUser(s.Proposer)
+	if err != nil {
+		return err
+	}
+	recipient, err := LoadUser(s.Recipient)
+	if err != nil {
+		return err
+	}
+	err = checkSwap(s, proposer)
+	if err != nil {
+		return err
+	}
+	err = checkSwapPolicy(s, proposer, recipient)
+	if err != nil {
+		return err
+	}
+
+	tx, err := middleware.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting swap transaction: %w", err)
+	}
+	err = switchOwners(tx, s, proposer, recipient)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Default().Printf("error rolling back swap %d: %s", id, rbErr)
+		}
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing swap %d: %w", id, err)
+	}
+	Audit(AuditSwap, "swap", id, s, map[string]string{"entry_owner": s.Recipient, "counter_owner": s.Proposer})
+	Notify(s.Proposer, fmt.Sprintf("%s hat deinen Vorschlag angenommen: %s.", s.Recipient, s.describe()))
+	return nil
+}
+
+// checkSwapPolicy validates the quotas of the accounts receiving an entry,
+// without the entry each of them gives away.
+func checkSwapPolicy(s Swap, proposer, recipient User) error {
+	received := s.Entry
+	received.User, received.HouseholdID = recipient.Name, recipient.Household
+	err := checkPolicy(received, &s.Entry, s.Counter.ID)
+	if err != nil || s.IsTransfer() {
+		return err
+	}
+	received = s.Counter
+	received.User, received.HouseholdID = proposer.Name, proposer.Household
+	return checkPolicy(received, &s.Counter, s.Entry.ID)
+}
+
+func switchOwners(tx *sql.Tx, s Swap, proposer, recipient User) error {
+	err := setOwner(tx, s.Entry, recipient)
+	if err != nil {
+		return err
+	}
+	if !s.IsTransfer() {
+		err = setOwner(tx, s.Counter, proposer)
+		if err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	_, err = tx.Exec("update swaps set status = ?, decided_at = ? where id = ?", SwapAccepted, now, s.ID)
+	if err != nil {
+		return fmt.Errorf("error accepting swap (%d): %w", s.ID, err)
+	}
+	args := []any{SwapObsolete, now, SwapRequested, s.ID, s.Entry.ID, s.Entry.ID}
+	cond := "entry_id = ? or counter_entry_id = ?"
+	if !s.IsTransfer() {
+		cond += " or entry_id = ? or counter_entry_id = ?"
+		args = append(args, s.Counter.ID, s.Counter.ID)
+	}
+	_, err = tx.Exec("update swaps set status = ?, decided_at = ? where status = ? and id <> ? and ("+cond+")", args...)
+	if err != nil {
+		return fmt.Errorf("error closing other swaps of swap (%d): %w", s.ID, err)
+	}
+	return nil
+}
+
+// setOwner hands the entry over unless it changed since the swap was loaded.
+func setOwner(tx *sql.Tx, entry Entry, owner User) error {
+	var household any
+	if owner.Household != 0 {
+		household = owner.Household
+	}
+	res, err := tx.Exec("update entries set user = ?, household_id = ? where res_id = ? and user = ?", owner.Name, household, entry.ID, entry.User)
+	if err != nil {
+		return fmt.Errorf("error changing owner of entry (%d): %w", entry.ID, err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return fmt.Errorf("entry %d changed owner meanwhile: %w", entry.ID, ErrConflict)
+	}
+	return nil
+}
+
+// DeclineSwap closes the proposal as recipient (declined) or as proposer
+// (withdrawn).
+func DeclineSwap(id int, user string) error {
+	s, err := LoadSwap(id)
+	if err != nil {
+		return err
+	}
+	status := SwapDeclined
+	notify := s.Proposer
+	switch {
+	case !s.IsOpen():
+		return fmt.Errorf("swap %d is closed already: %w", id, ErrForbidden)
+	case strings.EqualFold(s.Proposer, user):
+		status = SwapWithdrawn
+		notify = s.Recipient
+	case !strings.EqualFold(s.Recipient, user):
+		return fmt.Errorf("user %s may not decline swap %d: %w", user, id, ErrForbidden)
+	}
+	_, err = middleware.DB.Exec("update swaps set status = ?, decided_at = ? where id = ?", status, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("error closing swap (%d): %w", id, err)
+	}
+	Audit(AuditStatus, "swap", id, map[string]string{"status": s.Status}, map[string]string{"status": status})
+	Notify(notify, fmt.Sprintf("%s hat den Vorschlag %s: %s.", user, GermanSwapStatus[status], s.describe()))
+	return nil
+}
+
+func LoadSwap(id int) (Swap, error) {
+	swaps, err := querySwaps("id = ?", id)
+	if err != nil {
+		return Swap{}, err
+	}
+	if len(swaps) == 0 {
+		return Swap{}, fmt.Errorf("no swap found (%d): %w", id, ErrNotFound)
+	}
+	return swaps[0], nil
+}
+
+// LoadSwaps returns all proposals made or received by the user, newest
+// first.
+func LoadSwaps(user string) ([]Swap, error) {
+	return querySwaps("proposer = ? or recipient = ?", user, user)
+}
+
+func querySwaps(where string, args ...any) ([]Swap, error) {
+	rows, err := middleware.DB.Query("select id, entry_id, coalesce(counter_entry_id, 0), proposer, recipient, message, status, created_at, decided_at from swaps where "+where+" order by created_at desc", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching swaps: %w", err)
+	}
+	defer rows.Close()
+
+	swaps := []Swap{}
+	for rows.Next() {
+		var s Swap
+		var decided sql.NullTime
+		err = rows.Scan(&s.ID, &s.Entry.ID, &s.Counter.ID, &s.Proposer, &s.Recipient, &s.Message, &s.Status, &s.CreatedAt, &decided)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning swap: %w", err)
+		}
+		s.DecidedAt = decided.Time
+		swaps = append(swaps, s)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching swaps: %w", rows.Err())
+	}
+	rows.Close()
+
+	for i := range swaps {
+		swaps[i].Entry, err = LoadEntry(swaps[i].Entry.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if swaps[i].Counter.ID != 0 {
+			swaps[i].Counter, err = LoadEntry(swaps[i].Counter.ID)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+		}
+	}
+	return swaps, nil
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckSwap(t *testing.T) {
+	anna := User{Name: "anna", Household: 1}
+	mine := Entry{ID: 1, User: "anna", HouseholdID: 1, Status: StatusConfirmed}
+	family := Entry{ID: 2, User: "ben", HouseholdID: 1, Status: StatusConfirmed}
+	peters := Entry{ID: 3, User: "peter", Status: StatusConfirmed}
+	cancelled := Entry{ID: 4, User: "peter", Status: StatusCancelled}
+
+	tests := []struct {
+		swap Swap
+		want error
+	}{
+		{Swap{Entry: mine, Recipient: "peter"}, nil},
+		{Swap{Entry: family, Recipient: "peter"}, nil},
+		{Swap{Entry: mine, Counter: peters, Recipient: "peter"}, nil},
+		{Swap{Entry: peters, Recipient: "carla"}, ErrForbidden},
+		{Swap{Entry: mine, Recipient: "anna"}, ErrConflict},
+		{Swap{Entry: mine, Recipient: ""}, ErrConflict},
+		{Swap{Entry: mine, Counter: peters, Recipient: "carla"}, ErrConflict},
+		{Swap{Entry: mine, Counter: cancelled, Recipient: "peter"}, ErrConflict},
+		{Swap{Entry: Entry{ID: 5, User: "anna", Status: StatusDeclined}, Recipient: "peter"}, ErrForbidden},
+		{Swap{Entry: Entry{ID: 6, User: "oma", CreatedBy: "anna", Status: StatusConfirmed}, Recipient: "peter"}, ErrForbidden},
+		{Swap{Entry: Entry{ID: 7, User: "anna", CreatedBy: "anna", ContactName: "Herr Huber", Status: StatusConfirmed}, Recipient: "peter"}, ErrForbidden},
+	}
+	for i, tt := range tests {
+		err := checkSwap(tt.swap, anna)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("case %d: expected %v, got %v", i, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"franklyner/gores/middleware"
+	"time"
+)
+
+const (
+	notDeletedCond = "e.deleted_at is null"
+	deletedCond    = "e.deleted_at is not null"
+)
+
+// TrashRetentionDays is how long deleted entries can be restored.
+var TrashRetentionDays = 30
+
+// LoadTrash returns the deleted entries, most recently deleted first.
+func LoadTrash(page int) (EntryPage, error) {
+	return queryEntries(EntryFilter{
+		Deleted:  true,
+		Statuses: []string{StatusRequested, StatusConfirmed, StatusDeclined, StatusCancelled},
+		Page:     page,
+	}, "e.deleted_at desc")
+}
+
+// RestoreEntry takes the entry out of the trash. Whoever may change the entry
+// may restore it, active entries only if they do not conflict with bookings
+// made in the meantime.
+func RestoreEntry(id int, user string) (Entry, error) {
+	entry, err := loadEntry(id, deletedCond)
+	if err != nil {
+		return Entry{}, err
+	}
+	if !canModify(user, entry) {
+		Audit(AuditDenied, "entry", id, entry, nil)
+		return Entry{}, fmt.Errorf("user %s may not restore entry %d: %w", user, id, ErrForbidden)
+	}
+	if entry.IsActive() {
+		resource, resources, err := ResolveResource(entry.ResourceID)
+		if err != nil {
+			return Entry{}, err
+		}
+		err = checkAvailability(resources, resource, entry)
+		if err != nil {
+			return Entry{}, err
+		}
+	}
+	_, err = middleware.DB.Exec("update entries set deleted_at = null, deleted_by = null where res_id = ?", id)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error restoring entry (%d): %w", id, err)
+	}
+	Audit(AuditRestore, "entry", id, nil, entry)
+	entry.DeletedAt = time.Time{}
+	entry.DeletedBy = ""
+	return entry, nil
+}
+
+// PurgeTrash removes the entries deleted before the retention period for
+// good. There is no scheduler for the CGI, so it runs whenever the calendar
+// or the trash is shown; the delete is cheap when nothing is due.
+func PurgeTrash(now time.Time) error {
+	limit := now.AddDate(0, 0, -TrashRetentionDays)
+	res, err := middleware.DB.Exec("delete from entries where deleted_at < ?", limit)
+	if err != nil {
+		return fmt.Errorf("error purging trash: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		Audit(AuditPurge, "entry", 0, nil, map[string]any{"deleted_before": limit.Format(time.DateOnly), "count": n})
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"franklyner/gores/middleware"
+	"log"
+	"slices"
+	"time"
+)
+
+type WaitlistEntry struct {
+	ID         int
+	User       string
+	ResourceID int
+	Begin      time.Time
+	End        time.Time
+	Guests     int
+	AutoHold   bool // create a tentative entry as soon as the range is free
+	CreatedAt  time.Time
+	Notified   bool
+}
+
+func (w WaitlistEntry) entry() Entry {
+	return Entry{
+		User:        w.User,
+		ResourceID:  w.ResourceID,
+		Begin:       w.Begin,
+		End:         w.End,
+		Guests:      w.Guests,
+		Bemerkungen: "von der Warteliste",
+	}
+}
+
+func JoinWaitlist(w WaitlistEntry) error {
+	_, err := middleware.DB.Exec("insert into waitlist (user, resource_id, begin, end, guests, auto_hold, created_at) values (?,?,?,?,?,?,?)", w.User, w.ResourceID, w.Begin, w.End, w.Guests, w.AutoHold, time.Now())
+	if err != nil {
+		return fmt.Errorf("error inserting waitlist entry: %w", err)
+	}
+	return nil
+}
+
+func LeaveWaitlist(id int, user string) error {
+	_, err := middleware.DB.Exec("delete from waitlist where id = ? and user = ?", id, user)
+	if err != nil {
+		return fmt.Errorf("error deleting waitlist entry (%d): %w", id, err)
+	}
+	return nil
+}
+
+// LoadWaitlist returns the waitlist entries of the user which did not end yet.
+func LoadWaitlist(user string) ([]WaitlistEntry, error) {
+	return queryWaitlist("select id, user, resource_id, begin, end, guests, auto_hold, created_at, notified_at is not null from waitlist where user = ? and end >= ? order by begin", user, time.Now())
+}
+
+func queryWaitlist(query string, args ...any) ([]WaitlistEntry, error) {
+	rows, err := middleware.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	waitlist := []WaitlistEntry{}
+	for rows.Next() {
+		var w WaitlistEntry
+		err = rows.Scan(&w.ID, &w.User, &w.ResourceID, &w.Begin, &w.End, &w.Guests, &w.AutoHold, &w.CreatedAt, &w.Notified)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning waitlist entry: %w", err)
+		}
+		waitlist = append(waitlist, w)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error fetching waitlist: %w", rows.Err())
+	}
+	return waitlist, nil
+}
+
+// processWaitlist is called whenever the given range of a resource got (at
+// least partially) free. The first user waiting for an overlapping range
+// which is now available gets notified and, if requested, a tentative entry.
+func processWaitlist(resourceID int, begin, end time.Time) {
+	resources, err := LoadResources()
+	if err != nil {
+		log.Default().Printf("error processing waitlist: %s", err)
+		return
+	}
+	in, args := inClause(conflictScope(resources, resourceID))
+	args = append(args, begin, end)
+	waiting, err := queryWaitlist("select id, user, resource_id, begin, end, guests, auto_hold, created_at, false from waitlist where resource_id in "+in+" and notified_at is null and end >= ? and begin <= ? order by created_at", args...)
+	if err != nil {
+		log.Default().Printf("error processing waitlist: %s", err)
+		return
+	}
+
+	if len(waiting) == 0 {
+		return
+	}
+
+	// the waiting ranges may reach beyond the freed one, and waiting for a
+	// house conflicts with all of its rooms
+	scope := []int{}
+	from, to := waiting[0].Begin, waiting[0].End
+	for _, w := range waiting {
+		scope = append(scope, conflictScope(resources, w.ResourceID)...)
+		if w.Begin.Before(from) {
+			from = w.Begin
+		}
+		if w.End.After(to) {
+			to = w.End
+		}
+	}
+	blocks, err := loadBlocks(scope, from, to)
+	if err != nil {
+		log.Default().Printf("error processing waitlist: %s", err)
+		return
+	}
+	entries, err := loadEntries(scope, from, to)
+	if err != nil {
+		log.Default().Printf("error processing waitlist: %s", err)
+		return
+	}
+
+	w, ok := nextWaiting(waiting, resources, blocks, entries)
+	if !ok {
+		return
+	}
+	hold := ""
+	if w.AutoHold {
+		hold = holdFor(w)
+	}
+	Notify(w.User, waitlistMessage(resourceByID(resources, w.ResourceID), w, hold))
+	_, err = middleware.DB.Exec("update waitlist set notified_at = ? where id = ?", time.Now(), w.ID)
+	if err != nil {
+		log.Default().Printf("error marking waitlist entry %d as notified: %s", w.ID, err)
+	}
+}
+
+// nextWaiting returns the first of the waiting entries, in the order given,
+// whose range is available between the blocks and entries of its resource.
+func nextWaiting(waiting []WaitlistEntry, resources []Resource, blocks []Block, entries []Entry) (WaitlistEntry, bool) {
+	for _, w := range waiting {
+		scope := conflictScope(resources, w.ResourceID)
+		scopedBlocks := []Block{}
+		for _, b := range blocks {
+			if slices.Contains(scope, b.ResourceID) {
+				scopedBlocks = append(scopedBlocks, b)
+			}
+		}
+		scopedEntries := []Entry{}
+		for _, e := range entries {
+			if slices.Contains(scope, e.ResourceID) {
+				scopedEntries = append(scopedEntries, e)
+			}
+		}
+		if checkOccurrence(resourceByID(resources, w.ResourceID), w.entry(), scopedBlocks, scopedEntries) == nil {
+			return w, true
+		}
+	}
+	return WaitlistEntry{}, false
+}
+
+// waitlistMessage tells the waiting user that the range is free, hold is the
+// outcome of an automatic hold if one was requested.
+func waitlistMessage(resource Resource, w WaitlistEntry, hold string) string {
+	return fmt.Sprintf("%s vom %s bis %s ist wieder frei.", resource.Label, w.Begin.Format("02.01.2006"), w.End.Format("02.01.2006")) + hold
+}
+
+// holdFor creates a tentative entry for the waiting user and returns the
+// addition to the notification. Holds must follow the booking policy like
+// any other request.
+func holdFor(w WaitlistEntry) string {
+	e, err := attributeHousehold(w.entry())
+	if err != nil {
+		log.Default().Printf("error creating hold for waitlist entry %d: %s", w.ID, err)
+		return ""
+	}
+	err = checkPolicy(e, nil)
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		return " Sie konnte nicht vorgemerkt werden. " + policyErr.Error()
+	}
+	if err != nil {
+		log.Default().Printf("error checking hold for waitlist entry %d: %s", w.ID, err)
+		return ""
+	}
+	e.Status = StatusRequested
+	_, err = insertEntry(e)
+	if err != nil {
+		log.Default().Printf("error creating hold for waitlist entry %d: %s", w.ID, err)
+		return ""
+	}
+	return " Die Reservation wurde für dich vorgemerkt und muss noch bestätigt werden."
+}
+
+func resourceByID(resources []Resource, id int) Resource {
+	for _, r := range resources {
+		if r.ID == id {
+			return r
+		}
+	}
+	return Resource{ID: id}
+}
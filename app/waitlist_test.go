@@ -0,0 +1,52 @@
+package app
+
+import "testing"
+
+func TestNextWaiting(t *testing.T) {
+	resources := labelResources([]Resource{
+		{ID: 1, Name: "Chalet"},
+		{ID: 3, ParentID: 1, Name: "Zimmer 1"},
+		{ID: 4, ParentID: 1, Name: "Zimmer 2"},
+		{ID: 2, Name: "Stöckli"},
+	})
+	entries := []Entry{
+		{ID: 1, ResourceID: 3, Begin: date(2024, 7, 1), End: date(2024, 7, 5)},
+		{ID: 2, ResourceID: 1, Begin: date(2024, 8, 1), End: date(2024, 8, 5)},
+	}
+	blocks := []Block{{ID: 1, ResourceID: 2, Begin: date(2024, 7, 10), End: date(2024, 7, 12)}}
+
+	room1 := WaitlistEntry{ID: 1, ResourceID: 3, Begin: date(2024, 7, 2), End: date(2024, 7, 4)}
+	room2 := WaitlistEntry{ID: 2, ResourceID: 4, Begin: date(2024, 7, 2), End: date(2024, 7, 4)}
+	house := WaitlistEntry{ID: 3, ResourceID: 1, Begin: date(2024, 7, 2), End: date(2024, 7, 4)}
+	blocked := WaitlistEntry{ID: 4, ResourceID: 2, Begin: date(2024, 7, 11), End: date(2024, 7, 13)}
+	stoeckli := WaitlistEntry{ID: 5, ResourceID: 2, Begin: date(2024, 7, 2), End: date(2024, 7, 4)}
+	roomInHouse := WaitlistEntry{ID: 6, ResourceID: 4, Begin: date(2024, 8, 2), End: date(2024, 8, 3)}
+
+	tests := []struct {
+		name    string
+		waiting []WaitlistEntry
+		want    int // id, 0 for none
+	}{
+		{"room still booked", []WaitlistEntry{room1}, 0},
+		{"other room of the house is free", []WaitlistEntry{room1, room2}, 2},
+		{"house conflicts with its rooms", []WaitlistEntry{house, room2}, 2},
+		{"blocked", []WaitlistEntry{blocked}, 0},
+		{"first come first served", []WaitlistEntry{stoeckli, room2}, 5},
+		{"room conflicts with the house", []WaitlistEntry{roomInHouse}, 0},
+		{"nobody waiting", nil, 0},
+	}
+	for _, tt := range tests {
+		w, ok := nextWaiting(tt.waiting, resources, blocks, entries)
+		if ok != (tt.want != 0) || w.ID != tt.want {
+			t.Errorf("%s: expected %d, got %d (%t)", tt.name, tt.want, w.ID, ok)
+		}
+	}
+}
+
+func TestWaitlistMessage(t *testing.T) {
+	w := WaitlistEntry{Begin: date(2024, 7, 2), End: date(2024, 7, 4)}
+	msg := waitlistMessage(Resource{Label: "Chalet / Zimmer 1"}, w, " Vorgemerkt.")
+	if msg != "Chalet / Zimmer 1 vom 02.07.2024 bis 04.07.2024 ist wieder frei. Vorgemerkt." {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
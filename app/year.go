@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+type MonthOverview struct {
+	Month        int
+	Name         string
+	Weeks        []Week
+	BookedNights int
+}
+
+type YearOverview struct {
+	Resource     Resource
+	Resources    []Resource
+	PrevYear     int
+	Year         int
+	NextYear     int
+	WeekdayNames []string
+	Months       []MonthOverview
+	BookedNights int
+	Legend       []LegendItem
+}
+
+func LoadYearOverview(year int, firstWeekday time.Weekday, resourceID int) (YearOverview, error) {
+	resource, resources, err := ResolveResource(resourceID)
+	if err != nil {
+		return YearOverview{}, err
+	}
+	start, _ := gridRange(year, 1, firstWeekday)
+	decStart, decWeeks := gridRange(year, 12, firstWeekday)
+	end := decStart.AddDate(0, 0, decWeeks*7-1)
+
+	scope := conflictScope(resources, resource.ID)
+	entries, err := loadEntries(scope, start, end)
+	if err != nil {
+		return YearOverview{}, fmt.Errorf("error loading entries for year %d: %w", year, err)
+	}
+	blocks, err := loadBlocks(scope, start, end)
+	if err != nil {
+		return YearOverview{}, err
+	}
+	holidays := loadCalendarHolidays(start, end)
+	log.Default().Printf("found %d entries for year %d", len(entries), year)
+
+	overview := YearOverview{
+		Resource:     resource,
+		Resources:    resources,
+		PrevYear:     year - 1,
+		Year:         year,
+		NextYear:     year + 1,
+		WeekdayNames: weekdayNames(firstWeekday),
+		Months:       make([]MonthOverview, 0, 12),
+		Legend:       buildLegend(entries),
+	}
+	for month := 1; month <= 12; month++ {
+		nights := bookedNights(entries, year, month)
+		weeks := buildWeeks(year, month, firstWeekday, entries)
+		if resource.Capacity > 0 {
+			applyCapacity(weeks, entries, resource.Capacity)
+		}
+		applyBlocks(weeks, blocks)
+		applyHolidays(weeks, holidays)
+		overview.Months = append(overview.Months, MonthOverview{
+			Month:        month,
+			Name:         GermanMonths[month],
+			Weeks:        weeks,
+			BookedNights: nights,
+		})
+		overview.BookedNights += nights
+	}
+	return overview, nil
+}
+
+// bookedNights counts the nights of the given month covered by an entry. The
+// night belongs to the day it starts on, so an entry from the 1st to the 3rd
+// books two nights.
+func bookedNights(entries []Entry, year, month int) int {
+	nights := 0
+	day := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	for ; int(day.Month()) == month; day = day.AddDate(0, 0, 1) {
+		for _, e := range entries {
+			if !day.Before(e.Begin) && day.Before(e.End) {
+				nights++
+				break
+			}
+		}
+	}
+	return nights
+}
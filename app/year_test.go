@@ -0,0 +1,25 @@
+package app
+
+import "testing"
+
+func TestBookedNights(t *testing.T) {
+	entries := []Entry{
+		{Begin: date(2024, 1, 30), End: date(2024, 2, 2)},
+		{Begin: date(2024, 2, 10), End: date(2024, 2, 12)},
+		{Begin: date(2024, 2, 11), End: date(2024, 2, 13)},
+		{Begin: date(2024, 2, 28), End: date(2024, 3, 1)},
+	}
+	tests := []struct {
+		month  int
+		nights int
+	}{
+		{1, 2},
+		{2, 1 + 3 + 2},
+		{3, 0},
+	}
+	for _, tt := range tests {
+		if got := bookedNights(entries, 2024, tt.month); got != tt.nights {
+			t.Errorf("month %d: expected %d nights, got %d", tt.month, tt.nights, got)
+		}
+	}
+}
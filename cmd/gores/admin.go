@@ -0,0 +1,103 @@
+package main
+
+import (
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+)
+
+func showApprovals(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	page, err := app.ListEntries(app.EntryFilter{
+		Statuses: []string{app.StatusRequested},
+		PageSize: 100,
+	})
+	if err != nil {
+		log.Default().Printf("Error loading requested entries: %s\n", err.Error())
+		return true
+	}
+	rules, err := app.LoadApprovalRules()
+	if err != nil {
+		log.Default().Printf("Error loading approval rules: %s\n", err.Error())
+		return true
+	}
+	resources, err := app.LoadResources()
+	if err != nil {
+		log.Default().Printf("Error loading resources: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Requests":  page.Entries,
+		"Rules":     rules,
+		"Resources": resources,
+		"Message":   popMessage(),
+	}, "approvals.twig")
+}
+
+func doApprove(req middleware.Request, resp *middleware.Response) bool {
+	return changeStatus(req, resp, app.StatusConfirmed, "approvals")
+}
+
+func doDecline(req middleware.Request, resp *middleware.Response) bool {
+	return changeStatus(req, resp, app.StatusDeclined, "approvals")
+}
+
+func doCancel(req middleware.Request, resp *middleware.Response) bool {
+	return changeStatus(req, resp, app.StatusCancelled, "list?mine=1")
+}
+
+func changeStatus(req middleware.Request, resp *middleware.Response, status, next string) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.SetEntryStatus(id, status, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Der Status konnte nicht geändert werden.")
+	} else {
+		middleware.Session.Set("message", "Reservation "+app.GermanStatus[status]+".")
+	}
+	resp.SendRedirect(next)
+	return true
+}
+
+func doAddRule(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	period, err := app.ParsePeriod(req.Form.Get("label"), req.Form.Get("start"), req.Form.Get("end"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Ungültiger Zeitraum, bitte im Format MM-TT angeben.")
+		resp.SendRedirect("approvals")
+		return true
+	}
+	resourceID, _ := strconv.Atoi(req.Form.Get("r"))
+	err = app.CreateApprovalRule(app.ApprovalRule{
+		ResourceID: resourceID,
+		Period:     period,
+	})
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Etwas ist beim speichern schiefgelaufen...")
+	}
+	resp.SendRedirect("approvals")
+	return true
+}
+
+func doDeleteRule(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.DeleteApprovalRule(id)
+	if err != nil {
+		log.Default().Print(err)
+	}
+	resp.SendRedirect("approvals")
+	return true
+}
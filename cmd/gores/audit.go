@@ -0,0 +1,46 @@
+package main
+
+import (
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+)
+
+func showAudit(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	filter := app.AuditFilter{
+		Actor:      req.Query.Get("actor"),
+		Action:     req.Query.Get("action"),
+		ObjectType: req.Query.Get("type"),
+		From:       parseDate(req.Query.Get("from")),
+		To:         parseDate(req.Query.Get("to")),
+		PageSize:   50,
+	}
+	filter.ObjectID, _ = strconv.Atoi(req.Query.Get("id"))
+	filter.Page, _ = strconv.Atoi(req.Query.Get("p"))
+
+	page, err := app.LoadAuditLog(filter)
+	if err != nil {
+		log.Default().Printf("Error loading audit log: %s\n", err.Error())
+		return true
+	}
+	objectID := ""
+	if filter.ObjectID != 0 {
+		objectID = strconv.Itoa(filter.ObjectID)
+	}
+	return render(resp, map[string]any{
+		"Page":     page,
+		"Actor":    filter.Actor,
+		"Action":   filter.Action,
+		"Type":     filter.ObjectType,
+		"ObjectID": objectID,
+		"From":     formatDate(filter.From),
+		"To":       formatDate(filter.To),
+		"Actions": []string{app.AuditCreate, app.AuditUpdate, app.AuditDelete, app.AuditStatus, app.AuditDenied,
+			app.AuditLogin, app.AuditLoginFailed, app.AuditSwap, app.AuditSkip, app.AuditMaterialize, app.AuditLotteryDrawn,
+			app.AuditRestore, app.AuditPurge},
+	}, "audit.twig")
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func showBlocks(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	blocks, err := app.LoadUpcomingBlocks(today())
+	if err != nil {
+		log.Default().Printf("Error loading blocks: %s\n", err.Error())
+		return true
+	}
+	resources, err := app.LoadResources()
+	if err != nil {
+		log.Default().Printf("Error loading resources: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Blocks":    blocks,
+		"Resources": resources,
+		"Kinds":     app.GermanBlockKinds,
+		"Message":   popMessage(),
+	}, "blocks.twig")
+}
+
+func doAddBlock(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	resourceID, _ := strconv.Atoi(req.Form.Get("r"))
+	block := app.Block{
+		ResourceID: resourceID,
+		Begin:      parseDate(req.Form.Get("begin")),
+		End:        parseDate(req.Form.Get("end")),
+		Kind:       req.Form.Get("kind"),
+		Reason:     req.Form.Get("reason"),
+		CreatedBy:  middleware.Session.Get("username"),
+	}
+	if block.Begin.IsZero() || block.End.IsZero() {
+		middleware.Session.Set("message", "Bitte Anfangs- und Enddatum angeben.")
+		resp.SendRedirect("blocks")
+		return true
+	}
+	rule, err := parseRecurrence(req.Form)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Ungültige Wiederholung!")
+		resp.SendRedirect("blocks")
+		return true
+	}
+	block.Recurrence = rule
+	affected, err := app.CreateBlock(block)
+	if errors.Is(err, app.ErrInvalid) {
+		middleware.Session.Set("message", "Das Enddatum liegt vor dem Anfangsdatum.")
+		resp.SendRedirect("blocks")
+		return true
+	}
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Sperre konnte nicht gespeichert werden.")
+		resp.SendRedirect("blocks")
+		return true
+	}
+	if len(affected) > 0 {
+		names := make([]string, 0, len(affected))
+		for _, e := range affected {
+			names = append(names, e.User+" ("+e.Begin.Format("02.01.2006")+" - "+e.End.Format("02.01.2006")+")")
+		}
+		middleware.Session.Set("message", "Die Sperre überschneidet sich mit bestehenden Reservationen: "+strings.Join(names, ", "))
+	}
+	resp.SendRedirect("blocks")
+	return true
+}
+
+func doDeleteBlock(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.DeleteBlock(id)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Sperre konnte nicht gelöscht werden.")
+	}
+	resp.SendRedirect("blocks")
+	return true
+}
+
+func doSkipBlockOccurrence(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	occurrence := parseDate(req.Form.Get("d"))
+	err := app.SkipBlockOccurrence(id, occurrence)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Ausnahme konnte nicht gespeichert werden.")
+	}
+	resp.SendRedirect("blocks")
+	return true
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+)
+
+// bookForExternal is the value of the booking form's for field for entries
+// of external guests.
+const bookForExternal = "extern"
+
+func showDelegations(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	delegations, err := app.LoadDelegations()
+	if err != nil {
+		log.Default().Printf("Error loading delegations: %s\n", err.Error())
+		return true
+	}
+	users, err := app.LoadUserNames()
+	if err != nil {
+		log.Default().Printf("Error loading users: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Delegations": delegations,
+		"Users":       users,
+		"Message":     popMessage(),
+	}, "delegations.twig")
+}
+
+func doAddDelegation(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	d := app.Delegation{Delegate: req.Form.Get("delegate")}
+	if principal := req.Form.Get("principal"); principal != bookForExternal {
+		d.Principal = principal
+	}
+	err := app.CreateDelegation(d)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Vertretung konnte nicht angelegt werden.")
+	}
+	resp.SendRedirect("delegations")
+	return true
+}
+
+func doDeleteDelegation(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.DeleteDelegation(id)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Vertretung konnte nicht gelöscht werden.")
+	}
+	resp.SendRedirect("delegations")
+	return true
+}
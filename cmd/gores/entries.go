@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func showList(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	filter := app.EntryFilter{
+		From: today(),
+	}
+	mine := req.Query.Get("mine") == "1"
+	if mine {
+		filter.Household, _ = strconv.Atoi(middleware.Session.Get("household"))
+		if filter.Household == 0 {
+			filter.User = middleware.Session.Get("username")
+		}
+		filter.Statuses = []string{app.StatusRequested, app.StatusConfirmed, app.StatusDeclined, app.StatusCancelled}
+	}
+	if from := req.Query.Get("from"); from != "" {
+		filter.From = parseDate(from)
+	}
+	if to := req.Query.Get("to"); to != "" {
+		filter.To = parseDate(to)
+	}
+	filter.Page, _ = strconv.Atoi(req.Query.Get("p"))
+
+	page, err := app.ListEntries(filter)
+	if err != nil {
+		log.Default().Printf("Error listing entries: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Page":     page,
+		"Mine":     mine,
+		"Waitlist": loadWaitlist(mine),
+		"From":     formatDate(filter.From),
+		"To":       formatDate(filter.To),
+		"Message":  popMessage(),
+		"Undo":     popUndo(),
+	}, "list.twig")
+}
+
+func showSearch(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	filter := app.EntryFilter{
+		Text: req.Query.Get("q"),
+		User: req.Query.Get("user"),
+		From: parseDate(req.Query.Get("from")),
+		To:   parseDate(req.Query.Get("to")),
+	}
+	filter.Page, _ = strconv.Atoi(req.Query.Get("p"))
+
+	data := map[string]any{
+		"Query": filter.Text,
+		"User":  filter.User,
+		"From":  formatDate(filter.From),
+		"To":    formatDate(filter.To),
+	}
+	if filter.Text == "" && filter.User == "" && filter.From.IsZero() && filter.To.IsZero() {
+		return render(resp, data, "search.twig")
+	}
+
+	page, err := app.SearchEntries(filter)
+	if err != nil {
+		log.Default().Printf("Error searching entries: %s\n", err.Error())
+		return true
+	}
+	data["Page"] = page
+	return render(resp, data, "search.twig")
+}
+
+func showEdit(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Query.Get("id"))
+	entry, err := app.LoadEntry(id)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Diese Reservation gibt es nicht.")
+		resp.SendRedirect("list")
+		return true
+	}
+	return render(resp, map[string]any{
+		"Entry":   entry,
+		"Message": popMessage(),
+	}, "edit.twig")
+}
+
+func doUpdate(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	e := app.Entry{
+		ID:          id,
+		Begin:       parseDate(req.Form.Get("begin")),
+		End:         parseDate(req.Form.Get("end")),
+		Bemerkungen: req.Form.Get("bemerkung"),
+		Guests:      parseGuests(req.Form.Get("guests")),
+	}
+	e.Children = parseChildren(req.Form.Get("children"), e.Guests)
+	e.MemberAbsent = req.Form.Get("member_present") != "1"
+	e.GuestList = parseGuestNames(req.Form.Get("guest_names"))
+	if e.Begin.IsZero() || e.End.IsZero() || e.End.Before(e.Begin) {
+		middleware.Session.Set("message", "Ungültiges Datum!")
+		resp.SendRedirect("edit?id=" + strconv.Itoa(id))
+		return true
+	}
+	updated, err := app.UpdateEntry(e, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", saveErrorMessage(err))
+		resp.SendRedirect("edit?id=" + strconv.Itoa(id))
+		return true
+	}
+	middleware.Session.Set("message", "Reservation gespeichert. Kosten: "+updated.Cost.String())
+	resp.SendRedirect("list")
+	return true
+}
+
+// saveErrorMessage translates errors of creating or updating an entry into a
+// message for the user.
+func saveErrorMessage(err error) string {
+	var policyErr *app.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return policyErr.Error()
+	case errors.Is(err, app.ErrBlocked):
+		return "Das Haus ist in dieser Zeit gesperrt!"
+	case errors.Is(err, app.ErrCapacity):
+		return "Nicht genügend freie Betten!"
+	case errors.Is(err, app.ErrConflict):
+		return "Konflikt mit einer bestehenden Buchung!"
+	case errors.Is(err, app.ErrForbidden):
+		return "Diese Reservation darfst du nicht ändern."
+	default:
+		return "Etwas ist beim speichern schiefgelaufen..."
+	}
+}
+
+// parseRecurrence builds the RRULE from the recurrence fields of the booking
+// and block forms, empty for single bookings.
+func parseRecurrence(form url.Values) (string, error) {
+	freq := form.Get("freq")
+	if freq == "" {
+		return "", nil
+	}
+	rule := app.Rule{Freq: freq, Interval: 1}
+	if interval, err := strconv.Atoi(form.Get("interval")); err == nil && interval > 1 {
+		rule.Interval = interval
+	}
+	if count, err := strconv.Atoi(form.Get("count")); err == nil && count > 0 {
+		rule.Count = count
+	}
+	rule.Until = parseDate(form.Get("until"))
+	parsed, err := app.ParseRule(rule.String())
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// parseChildren reads the number of children among the guests.
+func parseChildren(s string, guests int) int {
+	children, err := strconv.Atoi(s)
+	if err != nil || children < 0 {
+		return 0
+	}
+	return min(children, guests)
+}
+
+// parseGuestNames reads the named guests, one per line.
+func parseGuestNames(s string) []app.Guest {
+	guests := []app.Guest{}
+	for _, line := range strings.Split(s, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			guests = append(guests, app.Guest{Name: name})
+		}
+	}
+	return guests
+}
+
+func parseGuests(s string) int {
+	guests, err := strconv.Atoi(s)
+	if err != nil || guests < 1 {
+		return 1
+	}
+	return guests
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseDate reads dates as sent by date inputs (2006-01-02). Invalid dates
+// result in the zero time.
+func parseDate(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		log.Default().Printf("invalid date %q: %s", s, err)
+		return time.Time{}
+	}
+	return d
+}
+
+func formatDate(d time.Time) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(time.DateOnly)
+}
+
+// popMessage returns the flash message of the session and removes it.
+func popMessage() string {
+	msg := middleware.Session.Get("message")
+	middleware.Session.Set("message", "")
+	return msg
+}
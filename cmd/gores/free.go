@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFreeWindowHints is the number of free windows suggested when a booking
+// conflicts.
+const maxFreeWindowHints = 3
+
+// weekdayOption is a weekday checkbox of the availability finder.
+type weekdayOption struct {
+	Day     int
+	Name    string
+	Checked bool
+}
+
+// parseFreeWindowQuery reads the finder's form. Without nights and dates the
+// length and start of the stay given by begin and end is searched for, e.g.
+// when coming from a conflicting booking.
+func parseFreeWindowQuery(req middleware.Request) app.FreeWindowQuery {
+	q := app.FreeWindowQuery{
+		ResourceID: currentResource(req),
+		Guests:     parseGuests(req.Query.Get("guests")),
+		Earliest:   parseDate(req.Query.Get("from")),
+		Latest:     parseDate(req.Query.Get("to")),
+	}
+	q.Nights, _ = strconv.Atoi(req.Query.Get("nights"))
+	begin, end := parseDate(req.Query.Get("begin")), parseDate(req.Query.Get("end"))
+	if q.Nights < 1 && end.After(begin) {
+		q.Nights = int(end.Sub(begin).Hours() / 24)
+	}
+	if q.Nights < 1 {
+		q.Nights = 7
+	}
+	if q.Earliest.IsZero() {
+		q.Earliest = begin.AddDate(0, 0, -14)
+	}
+	if q.Earliest.Before(today()) {
+		q.Earliest = today()
+	}
+	if q.Latest.IsZero() {
+		q.Latest = q.Earliest.AddDate(0, 3, 0)
+	}
+	for _, d := range req.Query["weekday"] {
+		if day, err := strconv.Atoi(d); err == nil && day >= 0 && day < 7 {
+			q.Weekdays = append(q.Weekdays, time.Weekday(day))
+		}
+	}
+	return q
+}
+
+func weekdayOptions(checked []time.Weekday) []weekdayOption {
+	options := make([]weekdayOption, 0, 7)
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		option := weekdayOption{Day: int(day), Name: app.GermanWeekdays[day]}
+		for _, c := range checked {
+			option.Checked = option.Checked || c == day
+		}
+		options = append(options, option)
+	}
+	return options
+}
+
+func showFreeWindows(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	q := parseFreeWindowQuery(req)
+	resource, resources, err := app.ResolveResource(q.ResourceID)
+	if err != nil {
+		log.Default().Printf("Error loading resources: %s\n", err.Error())
+		return true
+	}
+	q.ResourceID = resource.ID
+	windows, err := app.LoadFreeWindows(q)
+	if err != nil {
+		log.Default().Printf("Error finding free windows: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Query":     q,
+		"Resource":  resource,
+		"Resources": resources,
+		"Weekdays":  weekdayOptions(q.Weekdays),
+		"Windows":   windows,
+	}, "free.twig")
+}
+
+// freeWindowHint suggests free windows of the same length around a
+// conflicting booking, empty if there are none.
+func freeWindowHint(e app.Entry) string {
+	if e.Recurrence != "" || !e.End.After(e.Begin) {
+		return ""
+	}
+	q := app.FreeWindowQuery{
+		ResourceID: e.ResourceID,
+		Nights:     e.Nights(),
+		Guests:     e.Guests,
+		Earliest:   e.Begin.AddDate(0, 0, -14),
+		Latest:     e.End.AddDate(0, 0, 42),
+	}
+	if q.Earliest.Before(today()) {
+		q.Earliest = today()
+	}
+	windows, err := app.LoadFreeWindows(q)
+	if err != nil {
+		log.Default().Print(err)
+		return ""
+	}
+	if len(windows) == 0 {
+		return ""
+	}
+	periods := []string{}
+	for _, w := range windows[:min(len(windows), maxFreeWindowHints)] {
+		periods = append(periods, w.Begin.Format("02.01.2006")+" - "+w.End.Format("02.01.2006"))
+	}
+	return fmt.Sprintf("Frei für %d Nächte: %s", q.Nights, strings.Join(periods, ", "))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strings"
+)
+
+// holidaySource summarizes an ICS import for the admin page.
+type holidaySource struct {
+	Name  string
+	Kind  string
+	Count int
+}
+
+func holidaySources(holidays []app.Holiday) []holidaySource {
+	sources := []holidaySource{}
+	index := map[string]int{}
+	for _, h := range holidays {
+		i, ok := index[h.Source]
+		if !ok {
+			i = len(sources)
+			index[h.Source] = i
+			sources = append(sources, holidaySource{Name: h.Source, Kind: h.KindName()})
+		}
+		sources[i].Count++
+	}
+	return sources
+}
+
+func showHolidays(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	imported, err := app.LoadImportedHolidays()
+	if err != nil {
+		log.Default().Printf("Error loading holidays: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Bundled":  holidaySources(app.BundledHolidays),
+		"Imported": imported,
+		"Sources":  holidaySources(imported),
+		"Kinds":    app.GermanHolidayKinds,
+		"Message":  popMessage(),
+	}, "holidays.twig")
+}
+
+func doImportHolidays(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	source := strings.TrimSpace(req.Form.Get("source"))
+	count, err := app.ImportHolidays(strings.NewReader(req.Form.Get("ics")), req.Form.Get("kind"), source)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Ferien konnten nicht importiert werden.")
+	} else {
+		middleware.Session.Set("message", fmt.Sprintf("%d Einträge aus %s importiert.", count, source))
+	}
+	resp.SendRedirect("holidays")
+	return true
+}
+
+func doDeleteHolidays(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	err := app.DeleteHolidaySource(req.Form.Get("source"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Ferien konnten nicht gelöscht werden.")
+	}
+	resp.SendRedirect("holidays")
+	return true
+}
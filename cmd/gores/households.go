@@ -0,0 +1,83 @@
+package main
+
+import (
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+)
+
+func showHouseholds(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	households, err := app.LoadHouseholds()
+	if err != nil {
+		log.Default().Printf("Error loading households: %s\n", err.Error())
+		return true
+	}
+	users, err := app.LoadUserNames()
+	if err != nil {
+		log.Default().Printf("Error loading users: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Households": households,
+		"Users":      users,
+		"Message":    popMessage(),
+	}, "households.twig")
+}
+
+func doAddHousehold(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	err := app.CreateHousehold(req.Form.Get("name"), req.Form.Get("color"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Der Haushalt konnte nicht angelegt werden.")
+	}
+	resp.SendRedirect("households")
+	return true
+}
+
+func doSetHousehold(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	household, _ := strconv.Atoi(req.Form.Get("household"))
+	err := app.SetHousehold(req.Form.Get("user"), household)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Der Haushalt konnte nicht zugewiesen werden.")
+	}
+	resp.SendRedirect("households")
+	return true
+}
+
+func doSetHouseholdColor(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.SetHouseholdColor(id, req.Form.Get("color"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Farbe konnte nicht geändert werden.")
+	}
+	resp.SendRedirect("households")
+	return true
+}
+
+func doSetUserColor(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	err := app.SetUserColor(req.Form.Get("user"), req.Form.Get("color"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Farbe konnte nicht geändert werden.")
+	}
+	resp.SendRedirect("households")
+	return true
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+	"time"
+)
+
+func showGuests(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Query.Get("id"))
+	entry, err := app.LoadEntry(id)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Diese Reservation gibt es nicht.")
+		resp.SendRedirect("list")
+		return true
+	}
+	guests, err := app.LoadGuests(id)
+	if err != nil {
+		log.Default().Printf("Error loading guests: %s\n", err.Error())
+		return true
+	}
+	// empty rows for new guests, at least as many as the booking has
+	list := guests[id]
+	for len(list) < max(entry.Guests, len(guests[id])+2) {
+		list = append(list, app.Guest{Category: app.CategoryAdult})
+	}
+	return render(resp, map[string]any{
+		"Entry":            entry,
+		"Guests":           list,
+		"Categories":       app.GuestCategories,
+		"GermanCategories": app.GermanGuestCategories,
+		"Message":          popMessage(),
+	}, "guests.twig")
+}
+
+func doSaveGuests(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	names := req.Form["name"]
+	categories := req.Form["category"]
+	nationalities := req.Form["nationality"]
+	guests := make([]app.Guest, 0, len(names))
+	for i, name := range names {
+		g := app.Guest{Name: name}
+		if i < len(categories) {
+			g.Category = categories[i]
+		}
+		if i < len(nationalities) {
+			g.Nationality = nationalities[i]
+		}
+		guests = append(guests, g)
+	}
+	err := app.SaveGuests(id, guests, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", saveErrorMessage(err))
+	} else {
+		middleware.Session.Set("message", "Gäste gespeichert.")
+	}
+	resp.SendRedirect("guests?id=" + strconv.Itoa(id))
+	return true
+}
+
+// showTaxReport shows the tourist tax report of the period from..to, the
+// previous month by default, or exports it as CSV with format=csv.
+func showTaxReport(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	if !isTreasurer() {
+		resp.SendRedirect("main")
+		return true
+	}
+	firstOfMonth := today().AddDate(0, 0, 1-today().Day())
+	from := parseDate(req.Query.Get("from"))
+	if from.IsZero() {
+		from = firstOfMonth.AddDate(0, -1, 0)
+	}
+	to := parseDate(req.Query.Get("to"))
+	if to.IsZero() {
+		to = from.AddDate(0, 1, -1)
+	}
+
+	report, err := app.LoadTaxReport(from, to)
+	if err != nil {
+		log.Default().Printf("Error loading tax report: %s\n", err.Error())
+		return true
+	}
+	if req.Query.Get("format") == "csv" {
+		resp.Headers["Content-Type"] = "text/csv; charset=UTF-8"
+		resp.Headers["Content-Disposition"] = fmt.Sprintf("attachment; filename=\"kurtaxe-%s-%s.csv\"", from.Format(time.DateOnly), to.Format(time.DateOnly))
+		err = report.WriteCSV(resp.Body)
+		if err != nil {
+			log.Default().Printf("Error writing tax report: %s\n", err.Error())
+		}
+		return true
+	}
+	return render(resp, map[string]any{
+		"Report": report,
+		"From":   formatDate(from),
+		"To":     formatDate(to),
+	}, "kurtaxe.twig")
+}
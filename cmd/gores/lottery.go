@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func showLottery(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	year, err := strconv.Atoi(req.Query.Get("y"))
+	if err != nil {
+		year = time.Now().Year()
+	}
+	username := middleware.Session.Get("username")
+	data := map[string]any{
+		"Year":     year,
+		"PrevYear": year - 1,
+		"NextYear": year + 1,
+		"Message":  popMessage(),
+	}
+	round, err := app.LoadLotteryRound(year)
+	if errors.Is(err, app.ErrNotFound) {
+		return render(resp, data, "lottery.twig")
+	}
+	if err != nil {
+		log.Default().Printf("Error loading lottery: %s\n", err.Error())
+		return true
+	}
+	periods, err := app.LoadLotteryPeriods(year)
+	if err != nil {
+		log.Default().Printf("Error loading lottery periods: %s\n", err.Error())
+		return true
+	}
+	wishes, err := app.LoadWishes(year, username)
+	if err != nil {
+		log.Default().Printf("Error loading wishes: %s\n", err.Error())
+		return true
+	}
+	ranks := make(map[int]int, len(wishes))
+	for _, w := range wishes {
+		ranks[w.PeriodID] = w.Rank
+	}
+	rankOptions := make([]int, 0, len(periods))
+	for i := range periods {
+		rankOptions = append(rankOptions, i+1)
+	}
+	resultsOf := username
+	if middleware.Session.Get("role") == app.RoleAdmin {
+		resultsOf = ""
+	}
+	results, err := app.LoadLotteryResults(year, resultsOf)
+	if err != nil {
+		log.Default().Printf("Error loading lottery results: %s\n", err.Error())
+		return true
+	}
+
+	data["Round"] = round
+	data["IsOpen"] = round.IsOpen(time.Now())
+	data["Periods"] = periods
+	data["Ranks"] = ranks
+	data["RankOptions"] = rankOptions
+	data["Results"] = results
+	return render(resp, data, "lottery.twig")
+}
+
+func doSaveWishes(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	year, _ := strconv.Atoi(req.Form.Get("y"))
+	ranks := map[int]int{}
+	used := map[int]bool{}
+	for key := range req.Form {
+		if !strings.HasPrefix(key, "rank_") {
+			continue
+		}
+		periodID, err := strconv.Atoi(strings.TrimPrefix(key, "rank_"))
+		if err != nil {
+			continue
+		}
+		rank, err := strconv.Atoi(req.Form.Get(key))
+		if err != nil || rank < 1 {
+			continue
+		}
+		if used[rank] {
+			middleware.Session.Set("message", "Jeder Rang darf nur einmal vergeben werden.")
+			resp.SendRedirect("lottery?y=" + strconv.Itoa(year))
+			return true
+		}
+		used[rank] = true
+		ranks[periodID] = rank
+	}
+	err := app.SaveWishes(year, middleware.Session.Get("username"), ranks)
+	if err != nil {
+		log.Default().Print(err)
+		if errors.Is(err, app.ErrForbidden) {
+			middleware.Session.Set("message", "Wünsche können nur während der Anmeldefrist erfasst werden.")
+		} else if errors.Is(err, app.ErrInvalid) {
+			middleware.Session.Set("message", "Jeder Rang darf nur einmal vergeben werden.")
+		} else {
+			middleware.Session.Set("message", "Etwas ist beim speichern schiefgelaufen...")
+		}
+	} else {
+		middleware.Session.Set("message", "Wünsche gespeichert.")
+	}
+	resp.SendRedirect("lottery?y=" + strconv.Itoa(year))
+	return true
+}
+
+func doSaveLotteryRound(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	year, _ := strconv.Atoi(req.Form.Get("y"))
+	round := app.LotteryRound{
+		Year:         year,
+		RequestOpen:  parseDate(req.Form.Get("open")),
+		RequestClose: parseDate(req.Form.Get("close")),
+		Weighted:     req.Form.Get("weighted") != "",
+	}
+	if round.RequestOpen.IsZero() || round.RequestClose.Before(round.RequestOpen) {
+		middleware.Session.Set("message", "Ungültige Anmeldefrist!")
+	} else if err := app.SaveLotteryRound(round); err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Etwas ist beim speichern schiefgelaufen...")
+	}
+	resp.SendRedirect("lottery?y=" + strconv.Itoa(year))
+	return true
+}
+
+func doAddLotteryPeriod(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	year, _ := strconv.Atoi(req.Form.Get("y"))
+	resourceID, _ := strconv.Atoi(req.Form.Get("r"))
+	p := app.LotteryPeriod{
+		Year:       year,
+		ResourceID: resourceID,
+		Label:      req.Form.Get("label"),
+		Begin:      parseDate(req.Form.Get("begin")),
+		End:        parseDate(req.Form.Get("end")),
+	}
+	if resourceID == 0 {
+		resource, _, err := app.ResolveResource(0)
+		if err != nil {
+			log.Default().Print(err)
+		}
+		p.ResourceID = resource.ID
+	}
+	if p.Label == "" || p.Begin.IsZero() || p.End.Before(p.Begin) {
+		middleware.Session.Set("message", "Ungültiger Zeitraum!")
+	} else if err := app.AddLotteryPeriod(p); err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Etwas ist beim speichern schiefgelaufen...")
+	}
+	resp.SendRedirect("lottery?y=" + strconv.Itoa(year))
+	return true
+}
+
+func doDrawLottery(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	year, _ := strconv.Atoi(req.Form.Get("y"))
+	seed, err := strconv.ParseInt(req.Form.Get("seed"), 10, 64)
+	if err != nil {
+		seed = time.Now().UnixNano()
+	}
+	_, err = app.RunLottery(year, seed)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Verlosung konnte nicht durchgeführt werden.")
+	} else {
+		middleware.Session.Set("message", "Die Verlosung wurde mit Startwert "+strconv.FormatInt(seed, 10)+" durchgeführt.")
+	}
+	resp.SendRedirect("lottery?y=" + strconv.Itoa(year))
+	return true
+}
@@ -23,10 +23,38 @@ const (
 	ConfigBGColor        = "bg_color"
 	ConfigContentBGColor = "content_bg_color"
 	ConfigTitle          = "title"
+	ConfigFirstWeekday   = "first_weekday"
+	ConfigSMTPHost       = "smtp_host"
+	ConfigSMTPUser       = "smtp_user"
+	ConfigSMTPPassword   = "smtp_password"
+	ConfigSMTPFrom       = "smtp_from"
+	ConfigTrashRetention = "trash_retention_days"
+	ConfigHolidayRegions = "holiday_regions"
+
+	ConfigPolicyMinNights         = "policy_min_nights"
+	ConfigPolicyMaxNights         = "policy_max_nights"
+	ConfigPolicyMinNoticeDays     = "policy_min_notice_days"
+	ConfigPolicyMaxHorizonDays    = "policy_max_horizon_days"
+	ConfigPolicyNightsPerYear     = "policy_nights_per_year"
+	ConfigPolicyPeakNightsPerYear = "policy_peak_nights_per_year"
+	ConfigPolicyPeakPeriods       = "policy_peak_periods"
+	ConfigPolicyPeakHolidays      = "policy_peak_holidays"
+
+	ConfigPriceAdult            = "price_adult"
+	ConfigPriceChild            = "price_child"
+	ConfigPriceSeasons          = "price_seasons"
+	ConfigPriceWeekendSurcharge = "price_weekend_surcharge"
+	ConfigPriceCleaningFee      = "price_cleaning_fee"
+
+	ConfigTaxRates = "kurtaxe_rates"
 )
 
 var config map[string]string
 
+var templateFuncs = template.FuncMap{
+	"highlight": app.Highlight,
+}
+
 func main() {
 	confData, err := os.ReadFile("../.goresconf")
 	if err != nil {
@@ -44,6 +72,27 @@ func main() {
 		DBPassword: config[ConfigDBPwd],
 		RootPath:   "/cgi-bin/gores",
 	})
+	app.Mail = app.MailConfig{
+		Host:     config[ConfigSMTPHost],
+		User:     config[ConfigSMTPUser],
+		Password: config[ConfigSMTPPassword],
+		From:     config[ConfigSMTPFrom],
+	}
+	holidays, err := app.LoadHolidayFiles("../holidays", strings.Split(config[ConfigHolidayRegions], ","))
+	if err != nil {
+		log.Default().Printf("invalid config value for %s: %s", ConfigHolidayRegions, err)
+	}
+	app.BundledHolidays = holidays
+	app.BookingPolicy = loadPolicy()
+	app.Prices = loadPricing()
+	if rates, err := app.ParseTaxRates(config[ConfigTaxRates]); err == nil {
+		app.TaxRates = rates
+	} else {
+		log.Default().Printf("invalid config value for %s: %s", ConfigTaxRates, err)
+	}
+	if days, err := strconv.Atoi(config[ConfigTrashRetention]); err == nil {
+		app.TrashRetentionDays = days
+	}
 	log.Default().Print("Request start")
 	middleware.DefaultRouter.AddHandler("/env", showEnv)
 	middleware.DefaultRouter.AddHandler("/tmpl", testTmpl)
@@ -54,12 +103,141 @@ func main() {
 	middleware.DefaultRouter.AddHandler("/logout", doLogout)
 	middleware.DefaultRouter.AddHandler("/dologin", doLogin)
 	middleware.DefaultRouter.AddHandler("/main", showMain)
+	middleware.DefaultRouter.AddHandler("/year", showYear)
 	middleware.DefaultRouter.AddHandler("/doSave", doSave)
 	middleware.DefaultRouter.AddHandler("/doDelete", doDelete)
+	middleware.DefaultRouter.AddHandler("/doSkipOccurrence", doSkipOccurrence)
+	middleware.DefaultRouter.AddHandler("/doRestore", doRestore)
+	middleware.DefaultRouter.AddHandler("/trash", showTrash)
+	middleware.DefaultRouter.AddHandler("/list", showList)
+	middleware.DefaultRouter.AddHandler("/edit", showEdit)
+	middleware.DefaultRouter.AddHandler("/doUpdate", doUpdate)
+	middleware.DefaultRouter.AddHandler("/search", showSearch)
+	middleware.DefaultRouter.AddHandler("/doCancel", doCancel)
+	middleware.DefaultRouter.AddHandler("/doJoinWaitlist", doJoinWaitlist)
+	middleware.DefaultRouter.AddHandler("/free", showFreeWindows)
+	middleware.DefaultRouter.AddHandler("/doLeaveWaitlist", doLeaveWaitlist)
+
+	middleware.DefaultRouter.AddHandler("/swaps", showSwaps)
+	middleware.DefaultRouter.AddHandler("/doProposeSwap", doProposeSwap)
+	middleware.DefaultRouter.AddHandler("/doAcceptSwap", doAcceptSwap)
+	middleware.DefaultRouter.AddHandler("/doDeclineSwap", doDeclineSwap)
+
+	middleware.DefaultRouter.AddHandler("/statements", showStatements)
+	middleware.DefaultRouter.AddHandler("/statement", showStatement)
+	middleware.DefaultRouter.AddHandler("/statementPdf", showStatementPDF)
+	middleware.DefaultRouter.AddHandler("/doAddPayment", doAddPayment)
+	middleware.DefaultRouter.AddHandler("/doDeletePayment", doDeletePayment)
+
+	middleware.DefaultRouter.AddHandler("/guests", showGuests)
+	middleware.DefaultRouter.AddHandler("/doSaveGuests", doSaveGuests)
+	middleware.DefaultRouter.AddHandler("/kurtaxe", showTaxReport)
+
+	middleware.DefaultRouter.AddHandler("/lottery", showLottery)
+	middleware.DefaultRouter.AddHandler("/doSaveWishes", doSaveWishes)
+	middleware.DefaultRouter.AddHandler("/doSaveLotteryRound", doSaveLotteryRound)
+	middleware.DefaultRouter.AddHandler("/doAddLotteryPeriod", doAddLotteryPeriod)
+	middleware.DefaultRouter.AddHandler("/doDrawLottery", doDrawLottery)
+
+	middleware.DefaultRouter.AddHandler("/blocks", showBlocks)
+	middleware.DefaultRouter.AddHandler("/doAddBlock", doAddBlock)
+	middleware.DefaultRouter.AddHandler("/doDeleteBlock", doDeleteBlock)
+	middleware.DefaultRouter.AddHandler("/doSkipBlockOccurrence", doSkipBlockOccurrence)
+
+	middleware.DefaultRouter.AddHandler("/audit", showAudit)
+
+	middleware.DefaultRouter.AddHandler("/holidays", showHolidays)
+	middleware.DefaultRouter.AddHandler("/doImportHolidays", doImportHolidays)
+	middleware.DefaultRouter.AddHandler("/doDeleteHolidays", doDeleteHolidays)
+
+	middleware.DefaultRouter.AddHandler("/households", showHouseholds)
+	middleware.DefaultRouter.AddHandler("/delegations", showDelegations)
+	middleware.DefaultRouter.AddHandler("/doAddDelegation", doAddDelegation)
+	middleware.DefaultRouter.AddHandler("/doDeleteDelegation", doDeleteDelegation)
+	middleware.DefaultRouter.AddHandler("/doAddHousehold", doAddHousehold)
+	middleware.DefaultRouter.AddHandler("/doSetHousehold", doSetHousehold)
+	middleware.DefaultRouter.AddHandler("/doSetHouseholdColor", doSetHouseholdColor)
+	middleware.DefaultRouter.AddHandler("/doSetUserColor", doSetUserColor)
+	middleware.DefaultRouter.AddHandler("/profile", showProfile)
+	middleware.DefaultRouter.AddHandler("/doSaveColor", doSaveColor)
+
+	middleware.DefaultRouter.AddHandler("/rotation", showRotation)
+	middleware.DefaultRouter.AddHandler("/doSaveRotation", doSaveRotation)
+	middleware.DefaultRouter.AddHandler("/doMaterializeRotation", doMaterializeRotation)
+
+	middleware.DefaultRouter.AddHandler("/approvals", showApprovals)
+	middleware.DefaultRouter.AddHandler("/doApprove", doApprove)
+	middleware.DefaultRouter.AddHandler("/doDecline", doDecline)
+	middleware.DefaultRouter.AddHandler("/doAddRule", doAddRule)
+	middleware.DefaultRouter.AddHandler("/doDeleteRule", doDeleteRule)
 
 	middleware.DefaultRouter.Handle()
 }
 
+// loadPricing reads the prices from the config. Missing or invalid amounts
+// are free.
+func loadPricing() app.Pricing {
+	configMoney := func(key string) app.Money {
+		m, err := app.ParseMoney(config[key])
+		if err != nil {
+			log.Default().Printf("invalid config value for %s: %s", key, err)
+		}
+		return m
+	}
+	seasons, err := app.ParseSeasonRates(config[ConfigPriceSeasons])
+	if err != nil {
+		log.Default().Printf("invalid config value for %s: %s", ConfigPriceSeasons, err)
+	}
+	surcharge, _ := strconv.Atoi(config[ConfigPriceWeekendSurcharge])
+	return app.Pricing{
+		Adult:            configMoney(ConfigPriceAdult),
+		Child:            configMoney(ConfigPriceChild),
+		Seasons:          seasons,
+		WeekendSurcharge: surcharge,
+		CleaningFee:      configMoney(ConfigPriceCleaningFee),
+	}
+}
+
+// loadPolicy reads the booking policy from the config. Missing or invalid
+// values disable the rule.
+func loadPolicy() app.Policy {
+	configInt := func(key string) int {
+		if config[key] == "" {
+			return 0
+		}
+		v, err := strconv.Atoi(config[key])
+		if err != nil {
+			log.Default().Printf("invalid config value for %s: %s", key, err)
+		}
+		return v
+	}
+	peakPeriods, err := app.ParsePeriods(config[ConfigPolicyPeakPeriods])
+	if err != nil {
+		log.Default().Printf("invalid config value for %s: %s", ConfigPolicyPeakPeriods, err)
+	}
+	peakHolidays := []app.Holiday{}
+	for _, kind := range strings.Split(config[ConfigPolicyPeakHolidays], ",") {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		holidays, err := app.HolidaysOfKind(kind)
+		if err != nil {
+			log.Default().Printf("error loading peak holidays (%s): %s", kind, err)
+		}
+		peakHolidays = append(peakHolidays, holidays...)
+	}
+	return app.Policy{
+		MinNights:         configInt(ConfigPolicyMinNights),
+		MaxNights:         configInt(ConfigPolicyMaxNights),
+		MinNoticeDays:     configInt(ConfigPolicyMinNoticeDays),
+		MaxHorizonDays:    configInt(ConfigPolicyMaxHorizonDays),
+		NightsPerYear:     configInt(ConfigPolicyNightsPerYear),
+		PeakNightsPerYear: configInt(ConfigPolicyPeakNightsPerYear),
+		PeakPeriods:       peakPeriods,
+		PeakHolidays:      peakHolidays,
+	}
+}
+
 func showLogin(req middleware.Request, resp *middleware.Response) bool {
 	html := `
 	<form method="POST" action="/cgi-bin/gores/dologin">
@@ -83,6 +261,7 @@ func doLogin(req middleware.Request, resp *middleware.Response) bool {
 	if err != nil {
 		log.Default().Println(err)
 		if errors.Is(err, app.ErrNotFound) {
+			app.Audit(app.AuditLoginFailed, "user", 0, nil, map[string]string{"user": username})
 			middleware.SendError(http.StatusUnauthorized, "invalid username or password")
 		} else {
 			middleware.SendError(http.StatusInternalServerError, err.Error())
@@ -92,10 +271,14 @@ func doLogin(req middleware.Request, resp *middleware.Response) bool {
 
 	if user.Password != strings.TrimSpace(password) {
 		log.Default().Println(password)
+		app.Audit(app.AuditLoginFailed, "user", 0, nil, map[string]string{"user": username})
 		middleware.SendError(http.StatusUnauthorized, "invalid username or password")
 		return false
 	}
 	middleware.Session.Set("username", username)
+	middleware.Session.Set("role", user.Role)
+	middleware.Session.Set("household", strconv.Itoa(user.Household))
+	app.Audit(app.AuditLogin, "user", 0, nil, map[string]string{"user": username})
 	log.Default().Printf("set username %s to session, redirecting to main", middleware.Session.Get("username"))
 	resp.SendRedirect("main")
 	return false
@@ -111,6 +294,11 @@ func showMain(req middleware.Request, resp *middleware.Response) bool {
 	if !ensureAuth(resp) {
 		return true
 	}
+	// the calendar is shown after every login and most actions, which makes
+	// it the regular place to purge the trash
+	if err := app.PurgeTrash(time.Now()); err != nil {
+		log.Default().Print(err)
+	}
 	mstr := req.Query.Get("m")
 	ystr := req.Query.Get("y")
 	var mon int
@@ -133,7 +321,7 @@ func showMain(req middleware.Request, resp *middleware.Response) bool {
 		}
 	}
 	log.Default().Print("m: ", mon, " y:", year)
-	cal, err := app.LoadCalendarForMonth(year, mon)
+	cal, err := app.LoadCalendarForMonth(year, mon, app.ParseWeekday(config[ConfigFirstWeekday]), currentResource(req))
 	if err != nil {
 		log.Default().Printf("Error loading calendar: %s\n", err.Error())
 		return true
@@ -145,11 +333,25 @@ func showMain(req middleware.Request, resp *middleware.Response) bool {
 		fmt.Fprintf(resp.Body, "Error loading template: %s\n", err.Error())
 		return true
 	}
+	notifications, err := app.PopNotifications(middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Printf("Error loading notifications: %s\n", err.Error())
+	}
+	bookFor, bookExternal, err := app.BookingTargets(middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Printf("Error loading booking targets: %s\n", err.Error())
+	}
 	data := map[string]any{
-		"Cal":      cal,
-		"Username": middleware.Session.Get("username"),
-		"Message":  middleware.Session.Get("message"),
-		"Config":   config,
+		"Cal":           cal,
+		"Username":      middleware.Session.Get("username"),
+		"Message":       middleware.Session.Get("message"),
+		"Config":        config,
+		"IsAdmin":       middleware.Session.Get("role") == app.RoleAdmin,
+		"Notifications": notifications,
+		"WaitlistOffer": popWaitlistOffer(),
+		"Undo":          popUndo(),
+		"BookFor":       bookFor,
+		"BookExternal":  bookExternal,
 	}
 	middleware.Session.Set("message", "") // deleting message
 
@@ -178,6 +380,30 @@ func showMain(req middleware.Request, resp *middleware.Response) bool {
 	return true
 }
 
+func showYear(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	year := time.Now().Year()
+	if ystr := req.Query.Get("y"); ystr != "" {
+		var err error
+		year, err = strconv.Atoi(ystr)
+		if err != nil {
+			log.Default().Printf("Error reading year param: %s\n", err.Error())
+			return true
+		}
+	}
+	overview, err := app.LoadYearOverview(year, app.ParseWeekday(config[ConfigFirstWeekday]), currentResource(req))
+	if err != nil {
+		log.Default().Printf("Error loading year overview: %s\n", err.Error())
+		return true
+	}
+
+	return render(resp, map[string]any{
+		"Overview": overview,
+	}, "year.twig")
+}
+
 func doSave(req middleware.Request, resp *middleware.Response) bool {
 	byear, _ := strconv.Atoi(req.Form.Get("byear"))
 	bmonth, _ := strconv.Atoi(req.Form.Get("bmonth"))
@@ -190,25 +416,58 @@ func doSave(req middleware.Request, resp *middleware.Response) bool {
 	end := time.Date(eyear, time.Month(emonth), eday, 0, 0, 0, 0, time.UTC)
 
 	username := middleware.Session.Get("username")
+	resourceID, _ := strconv.Atoi(req.Form.Get("r"))
 	e := app.Entry{
+		ResourceID:  resourceID,
 		User:        username,
+		CreatedBy:   username,
 		Begin:       start,
 		End:         end,
 		Bemerkungen: req.Form.Get("bemerkung"),
+		Guests:      parseGuests(req.Form.Get("guests")),
 	}
-	err := app.CreateEntry(e)
+	e.Children = parseChildren(req.Form.Get("children"), e.Guests)
+	e.MemberAbsent = req.Form.Get("member_present") != "1"
+	e.GuestList = parseGuestNames(req.Form.Get("guest_names"))
+	switch beneficiary := req.Form.Get("for"); beneficiary {
+	case "":
+	case bookForExternal:
+		e.ContactName = strings.TrimSpace(req.Form.Get("contact_name"))
+		e.ContactInfo = strings.TrimSpace(req.Form.Get("contact_info"))
+	default:
+		e.User = beneficiary
+	}
+	m := req.Form.Get("m")
+	y := req.Form.Get("y")
+	path := fmt.Sprintf("main?m=%s&y=%s", m, y)
+
+	rule, err := parseRecurrence(req.Form)
 	if err != nil {
 		log.Default().Print(err)
+		middleware.Session.Set("message", "Ungültige Wiederholung!")
+		resp.SendRedirect(path)
+		return true
+	}
+	e.Recurrence = rule
+	created, err := app.CreateEntry(e)
+	if err != nil {
+		log.Default().Print(err)
+		msg := saveErrorMessage(err)
 		if errors.Is(err, app.ErrConflict) {
-			middleware.Session.Set("message", "Konflikt mit einer bestehenden Buchung!")
-		} else {
-			middleware.Session.Set("message", "Etwas ist beim speichern schiefgelaufen...")
+			// no cancellation frees a blocked period
+			if !errors.Is(err, app.ErrBlocked) {
+				offerWaitlist(e)
+			}
+			if hint := freeWindowHint(e); hint != "" {
+				msg += " " + hint
+			}
 		}
+		middleware.Session.Set("message", msg)
+	} else if created.IsTentative() {
+		middleware.Session.Set("message", "Reservation angefragt, sie muss noch bestätigt werden. Kosten: "+created.Cost.String())
+	} else {
+		middleware.Session.Set("message", "Reservation gespeichert. Kosten: "+created.Cost.String())
 	}
-	m := req.Form.Get("m")
-	y := req.Form.Get("y")
-
-	path := fmt.Sprintf("main?m=%s&y=%s", m, y)
 	resp.SendRedirect(path)
 	return true
 }
@@ -221,13 +480,34 @@ func doDelete(req middleware.Request, resp *middleware.Response) bool {
 	err := app.DeleteEntry(entryID, user)
 	if err != nil {
 		log.Default().Print(err)
+		middleware.Session.Set("message", saveErrorMessage(err))
+	} else {
+		middleware.Session.Set("message", "Reservation gelöscht.")
+		middleware.Session.Set("undo", strconv.Itoa(entryID))
 	}
 
 	path := fmt.Sprintf("main?m=%s&y=%s", m, y)
+	if req.Query.Get("next") == "list" {
+		path = "list"
+	}
 	resp.SendRedirect(path)
 	return true
 }
 
+func doSkipOccurrence(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	entryID, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.SkipOccurrence(entryID, parseDate(req.Form.Get("d")), middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Der Termin konnte nicht ausgelassen werden.")
+	}
+	resp.SendRedirect(fmt.Sprintf("main?m=%s&y=%s", req.Form.Get("m"), req.Form.Get("y")))
+	return true
+}
+
 func showEnv(req middleware.Request, resp *middleware.Response) bool {
 	env := os.Environ()
 	fmt.Fprintln(resp.Body, "<b>Env</b></br>")
@@ -305,6 +585,38 @@ func testTmpl(req middleware.Request, resp *middleware.Response) bool {
 	return true
 }
 
+// render executes the given templates (relative to the templates folder) with
+// data. Username and Config are always available to the templates.
+func render(resp *middleware.Response, data map[string]any, files ...string) bool {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, "../templates/"+f)
+	}
+	tmpl, err := template.New(files[0]).Funcs(templateFuncs).ParseFiles(paths...)
+	if err != nil {
+		fmt.Fprintf(resp.Body, "Error loading template: %s\n", err.Error())
+		return true
+	}
+	data["Username"] = middleware.Session.Get("username")
+	data["IsAdmin"] = middleware.Session.Get("role") == app.RoleAdmin
+	data["Config"] = config
+	err = tmpl.Execute(resp.Body, data)
+	if err != nil {
+		fmt.Fprintf(resp.Body, "Error executing template: %s\n", err.Error())
+	}
+	return true
+}
+
+// currentResource returns the resource chosen with the r parameter and
+// remembers it in the session for later requests.
+func currentResource(req middleware.Request) int {
+	if r := req.Query.Get("r"); r != "" {
+		middleware.Session.Set("resource", r)
+	}
+	id, _ := strconv.Atoi(middleware.Session.Get("resource"))
+	return id
+}
+
 func ensureAuth(resp *middleware.Response) bool {
 	username := middleware.Session.Get("username")
 	if username == "" {
@@ -313,3 +625,14 @@ func ensureAuth(resp *middleware.Response) bool {
 	}
 	return true
 }
+
+func ensureAdmin(resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return false
+	}
+	if middleware.Session.Get("role") != app.RoleAdmin {
+		resp.SendRedirect("main")
+		return false
+	}
+	return true
+}
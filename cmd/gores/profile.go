@@ -0,0 +1,42 @@
+package main
+
+import (
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+)
+
+func showProfile(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	color, err := app.AccountColor(middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Printf("Error loading color: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Color":        color,
+		"HasHousehold": middleware.Session.Get("household") != "" && middleware.Session.Get("household") != "0",
+		"Message":      popMessage(),
+	}, "profile.twig")
+}
+
+func doSaveColor(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	color := req.Form.Get("color")
+	if req.Form.Get("reset") != "" {
+		color = ""
+	}
+	err := app.SetAccountColor(middleware.Session.Get("username"), color)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Farbe konnte nicht gespeichert werden.")
+	} else {
+		middleware.Session.Set("message", "Farbe gespeichert.")
+	}
+	resp.SendRedirect("profile")
+	return true
+}
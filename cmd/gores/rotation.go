@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const rotationPreviewYears = 6
+
+func showRotation(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	rotations, err := app.LoadRotations()
+	if err != nil {
+		log.Default().Printf("Error loading rotations: %s\n", err.Error())
+		return true
+	}
+	resources, err := app.LoadResources()
+	if err != nil {
+		log.Default().Printf("Error loading resources: %s\n", err.Error())
+		return true
+	}
+	households, err := app.LoadHouseholds()
+	if err != nil {
+		log.Default().Printf("Error loading households: %s\n", err.Error())
+		return true
+	}
+	data := map[string]any{
+		"Rotations":  rotations,
+		"Resources":  resources,
+		"Households": households,
+		"Year":       time.Now().Year(),
+		"Message":    popMessage(),
+	}
+
+	id, _ := strconv.Atoi(req.Query.Get("id"))
+	if id == 0 {
+		return render(resp, data, "rotation.twig")
+	}
+	rotation, err := app.LoadRotation(id)
+	if err != nil {
+		log.Default().Print(err)
+		return render(resp, data, "rotation.twig")
+	}
+	from, err := strconv.Atoi(req.Query.Get("from"))
+	if err != nil {
+		from = time.Now().Year()
+	}
+	schedule, err := app.PreviewRotation(rotation, from, rotationPreviewYears)
+	if err != nil {
+		log.Default().Printf("Error previewing rotation: %s\n", err.Error())
+		return true
+	}
+	data["Rotation"] = rotation
+	data["Schedule"] = schedule
+	data["From"] = from
+	data["PrevFrom"] = from - rotationPreviewYears
+	data["NextFrom"] = from + rotationPreviewYears
+	return render(resp, data, "rotation.twig")
+}
+
+func doSaveRotation(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	periods, err := app.ParsePeriods(strings.ReplaceAll(req.Form.Get("periods"), "\n", ","))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Ungültige Zeiträume, bitte als Bezeichnung:MM-TT:MM-TT angeben.")
+		resp.SendRedirect("rotation")
+		return true
+	}
+	households, err := app.LoadHouseholds()
+	if err != nil {
+		log.Default().Printf("Error loading households: %s\n", err.Error())
+		return true
+	}
+	rotating, err := app.ParseHouseholds(req.Form.Get("households"), households)
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Unbekannter oder doppelter Haushalt: "+req.Form.Get("households"))
+		resp.SendRedirect("rotation")
+		return true
+	}
+	startYear, _ := strconv.Atoi(req.Form.Get("start_year"))
+	resourceID, _ := strconv.Atoi(req.Form.Get("r"))
+	err = app.SaveRotation(app.Rotation{
+		Name:       req.Form.Get("name"),
+		ResourceID: resourceID,
+		StartYear:  startYear,
+		Households: rotating,
+		Periods:    periods,
+	})
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Rotation braucht Haushalte und Zeiträume.")
+	}
+	resp.SendRedirect("rotation")
+	return true
+}
+
+func doMaterializeRotation(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	year, _ := strconv.Atoi(req.Form.Get("y"))
+	next := "rotation?id=" + strconv.Itoa(id) + "&from=" + strconv.Itoa(year)
+	rotation, err := app.LoadRotation(id)
+	if err != nil {
+		log.Default().Print(err)
+		resp.SendRedirect("rotation")
+		return true
+	}
+	problems, err := app.MaterializeRotation(rotation, year)
+	switch {
+	case errors.Is(err, app.ErrConflict):
+		middleware.Session.Set("message", "Die Rotation wurde für "+strconv.Itoa(year)+" bereits eingetragen.")
+	case err != nil:
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Etwas ist beim speichern schiefgelaufen...")
+	case len(problems) > 0:
+		middleware.Session.Set("message", strings.Join(problems, " "))
+	default:
+		middleware.Session.Set("message", "Die Rotation wurde für "+strconv.Itoa(year)+" eingetragen.")
+	}
+	resp.SendRedirect(next)
+	return true
+}
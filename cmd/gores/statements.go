@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func showStatements(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	year := statementYear(req.Query)
+	if !isTreasurer() {
+		resp.SendRedirect(fmt.Sprintf("statement?y=%d&%s", year, ownAccount().Query()))
+		return true
+	}
+	statements, err := app.LoadStatements(year)
+	if err != nil {
+		log.Default().Printf("Error loading statements: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Statements": statements,
+		"Year":       year,
+		"PrevYear":   year - 1,
+		"NextYear":   year + 1,
+		"Today":      formatDate(today()),
+		"Message":    popMessage(),
+	}, "statements.twig")
+}
+
+func showStatement(req middleware.Request, resp *middleware.Response) bool {
+	statement, ok := loadStatement(req, resp)
+	if !ok {
+		return true
+	}
+	return render(resp, map[string]any{
+		"Statement":   statement,
+		"IsTreasurer": isTreasurer(),
+		"PrevYear":    statement.Year - 1,
+		"NextYear":    statement.Year + 1,
+		"Today":       formatDate(today()),
+		"Message":     popMessage(),
+	}, "statement.twig")
+}
+
+func showStatementPDF(req middleware.Request, resp *middleware.Response) bool {
+	statement, ok := loadStatement(req, resp)
+	if !ok {
+		return true
+	}
+	resp.Headers["Content-Type"] = "application/pdf"
+	resp.Headers["Content-Disposition"] = fmt.Sprintf("inline; filename=\"abrechnung-%d.pdf\"", statement.Year)
+	resp.Body.Write(app.StatementPDF(statement, config["title"]))
+	return true
+}
+
+func doAddPayment(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	p := app.Payment{
+		Year:    statementYear(req.Form),
+		Account: requestedAccount(req.Form),
+		PaidOn:  parseDate(req.Form.Get("paid_on")),
+		Note:    req.Form.Get("note"),
+	}
+	if p.PaidOn.IsZero() {
+		p.PaidOn = today()
+	}
+	amount, err := app.ParseMoney(req.Form.Get("amount"))
+	if err == nil {
+		p.Amount = amount
+		err = app.RecordPayment(p, middleware.Session.Get("username"))
+	}
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Zahlung konnte nicht erfasst werden.")
+	} else {
+		middleware.Session.Set("message", "Zahlung von "+p.Amount.String()+" erfasst.")
+	}
+	redirectToStatements(req.Form, resp, p.Year, p.Account)
+	return true
+}
+
+func doDeletePayment(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.DeletePayment(id, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Die Zahlung konnte nicht gelöscht werden.")
+	}
+	redirectToStatements(req.Form, resp, statementYear(req.Form), requestedAccount(req.Form))
+	return true
+}
+
+// loadStatement loads the statement chosen by the y, household and user
+// parameters. Members only get the statement of their own account.
+func loadStatement(req middleware.Request, resp *middleware.Response) (app.Statement, bool) {
+	if !ensureAuth(resp) {
+		return app.Statement{}, false
+	}
+	account := requestedAccount(req.Query)
+	if !isTreasurer() {
+		account = ownAccount()
+	}
+	statement, err := app.LoadStatement(statementYear(req.Query), account)
+	if err != nil {
+		log.Default().Printf("Error loading statement: %s\n", err.Error())
+		return app.Statement{}, false
+	}
+	return statement, true
+}
+
+func redirectToStatements(params url.Values, resp *middleware.Response, year int, account app.Account) {
+	if params.Get("next") == "statement" {
+		resp.SendRedirect(fmt.Sprintf("statement?y=%d&%s", year, account.Query()))
+		return
+	}
+	resp.SendRedirect(fmt.Sprintf("statements?y=%d", year))
+}
+
+// requestedAccount reads the account from the household or, if not set, the
+// user parameter.
+func requestedAccount(params url.Values) app.Account {
+	if household, _ := strconv.Atoi(params.Get("household")); household != 0 {
+		return app.Account{HouseholdID: household}
+	}
+	return app.Account{User: params.Get("user")}
+}
+
+// ownAccount is the account the logged in user's bookings are billed to.
+func ownAccount() app.Account {
+	if household, _ := strconv.Atoi(middleware.Session.Get("household")); household != 0 {
+		return app.Account{HouseholdID: household}
+	}
+	return app.Account{User: middleware.Session.Get("username")}
+}
+
+func isTreasurer() bool {
+	role := middleware.Session.Get("role")
+	return role == app.RoleTreasurer || role == app.RoleAdmin
+}
+
+// statementYear reads the y parameter, the previous year by default as
+// statements are usually made after the year has ended.
+func statementYear(params url.Values) int {
+	if year, err := strconv.Atoi(params.Get("y")); err == nil && year > 0 {
+		return year
+	}
+	return time.Now().Year() - 1
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+)
+
+func showSwaps(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	username := middleware.Session.Get("username")
+	swaps, err := app.LoadSwaps(username)
+	if err != nil {
+		log.Default().Printf("Error loading swaps: %s\n", err.Error())
+		return true
+	}
+	data := map[string]any{
+		"Swaps":   swaps,
+		"Message": popMessage(),
+	}
+
+	id, _ := strconv.Atoi(req.Query.Get("entry"))
+	if id == 0 {
+		return render(resp, data, "swaps.twig")
+	}
+	entry, err := app.LoadEntry(id)
+	if err != nil {
+		log.Default().Print(err)
+		return render(resp, data, "swaps.twig")
+	}
+	page, err := app.ListEntries(app.EntryFilter{From: today(), PageSize: 200})
+	if err != nil {
+		log.Default().Printf("Error listing entries: %s\n", err.Error())
+		return true
+	}
+	candidates := []app.Entry{}
+	for _, e := range page.Entries {
+		if !e.IsOwn {
+			candidates = append(candidates, e)
+		}
+	}
+	users, err := app.LoadUserNames()
+	if err != nil {
+		log.Default().Printf("Error loading users: %s\n", err.Error())
+		return true
+	}
+	recipients := []string{}
+	for _, u := range users {
+		if u != username && u != entry.User {
+			recipients = append(recipients, u)
+		}
+	}
+	data["Entry"] = entry
+	data["Candidates"] = candidates
+	data["Recipients"] = recipients
+	return render(resp, data, "swaps.twig")
+}
+
+func doProposeSwap(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	entryID, _ := strconv.Atoi(req.Form.Get("entry"))
+	counterID, _ := strconv.Atoi(req.Form.Get("counter"))
+	err := app.ProposeSwap(entryID, counterID, req.Form.Get("recipient"), req.Form.Get("message"), middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", swapErrorMessage(err))
+		resp.SendRedirect("swaps?entry=" + strconv.Itoa(entryID))
+		return true
+	}
+	middleware.Session.Set("message", "Der Vorschlag wurde verschickt.")
+	resp.SendRedirect("swaps")
+	return true
+}
+
+func doAcceptSwap(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.AcceptSwap(id, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", swapErrorMessage(err))
+	} else {
+		middleware.Session.Set("message", "Der Tausch wurde durchgeführt.")
+	}
+	resp.SendRedirect("swaps")
+	return true
+}
+
+func doDeclineSwap(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.DeclineSwap(id, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", swapErrorMessage(err))
+	}
+	resp.SendRedirect("swaps")
+	return true
+}
+
+func swapErrorMessage(err error) string {
+	var policyErr *app.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return policyErr.Error()
+	case errors.Is(err, app.ErrForbidden):
+		return "Das ist nicht deine Reservation oder der Vorschlag ist nicht mehr offen."
+	case errors.Is(err, app.ErrConflict):
+		return "Der Tausch ist so nicht möglich, eine der Reservationen hat sich geändert."
+	case errors.Is(err, app.ErrNotFound):
+		return "Reservation oder Mitglied nicht gefunden."
+	default:
+		return "Etwas ist beim speichern schiefgelaufen..."
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+	"time"
+)
+
+func showTrash(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAdmin(resp) {
+		return true
+	}
+	err := app.PurgeTrash(time.Now())
+	if err != nil {
+		log.Default().Print(err)
+	}
+	p, _ := strconv.Atoi(req.Query.Get("p"))
+	page, err := app.LoadTrash(p)
+	if err != nil {
+		log.Default().Printf("Error loading trash: %s\n", err.Error())
+		return true
+	}
+	return render(resp, map[string]any{
+		"Page":      page,
+		"Retention": app.TrashRetentionDays,
+		"Message":   popMessage(),
+	}, "trash.twig")
+}
+
+func doRestore(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	_, err := app.RestoreEntry(id, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Wiederherstellen nicht möglich: "+saveErrorMessage(err))
+	} else {
+		middleware.Session.Set("message", "Reservation wiederhergestellt.")
+	}
+
+	switch req.Form.Get("next") {
+	case "list":
+		resp.SendRedirect("list")
+	case "trash":
+		resp.SendRedirect("trash")
+	default:
+		resp.SendRedirect(fmt.Sprintf("main?m=%s&y=%s", req.Form.Get("m"), req.Form.Get("y")))
+	}
+	return true
+}
+
+// popUndo returns the id of the entry deleted by the previous request, if
+// any, and forgets it.
+func popUndo() string {
+	id := middleware.Session.Get("undo")
+	if id != "" {
+		middleware.Session.Set("undo", "")
+	}
+	return id
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"franklyner/gores/app"
+	"franklyner/gores/middleware"
+	"log"
+	"strconv"
+)
+
+// offerWaitlist remembers the conflicting entry in the session so the next
+// page can offer to join the waitlist for it.
+func offerWaitlist(e app.Entry) {
+	middleware.Session.Set("waitlist_resource", strconv.Itoa(e.ResourceID))
+	middleware.Session.Set("waitlist_begin", formatDate(e.Begin))
+	middleware.Session.Set("waitlist_end", formatDate(e.End))
+	middleware.Session.Set("waitlist_guests", strconv.Itoa(e.Guests))
+}
+
+// popWaitlistOffer returns the entry offered to be waitlisted, nil if there
+// is none.
+func popWaitlistOffer() *app.WaitlistEntry {
+	begin := parseDate(middleware.Session.Get("waitlist_begin"))
+	if begin.IsZero() {
+		return nil
+	}
+	resourceID, _ := strconv.Atoi(middleware.Session.Get("waitlist_resource"))
+	offer := &app.WaitlistEntry{
+		ResourceID: resourceID,
+		Begin:      begin,
+		End:        parseDate(middleware.Session.Get("waitlist_end")),
+		Guests:     parseGuests(middleware.Session.Get("waitlist_guests")),
+	}
+	middleware.Session.Set("waitlist_begin", "")
+	return offer
+}
+
+func doJoinWaitlist(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	resourceID, _ := strconv.Atoi(req.Form.Get("r"))
+	w := app.WaitlistEntry{
+		User:       middleware.Session.Get("username"),
+		ResourceID: resourceID,
+		Begin:      parseDate(req.Form.Get("begin")),
+		End:        parseDate(req.Form.Get("end")),
+		Guests:     parseGuests(req.Form.Get("guests")),
+		AutoHold:   req.Form.Get("auto_hold") != "",
+	}
+	if w.Begin.IsZero() || w.End.Before(w.Begin) {
+		middleware.Session.Set("message", "Ungültiges Datum!")
+	} else if err := app.JoinWaitlist(w); err != nil {
+		log.Default().Print(err)
+		middleware.Session.Set("message", "Etwas ist beim speichern schiefgelaufen...")
+	} else {
+		middleware.Session.Set("message", "Du bist auf der Warteliste und wirst benachrichtigt, sobald der Zeitraum frei wird.")
+	}
+	resp.SendRedirect("main?m=" + strconv.Itoa(int(w.Begin.Month())) + "&y=" + strconv.Itoa(w.Begin.Year()))
+	return true
+}
+
+func doLeaveWaitlist(req middleware.Request, resp *middleware.Response) bool {
+	if !ensureAuth(resp) {
+		return true
+	}
+	id, _ := strconv.Atoi(req.Form.Get("id"))
+	err := app.LeaveWaitlist(id, middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Print(err)
+	}
+	resp.SendRedirect("list?mine=1")
+	return true
+}
+
+func loadWaitlist(mine bool) []app.WaitlistEntry {
+	if !mine {
+		return nil
+	}
+	waitlist, err := app.LoadWaitlist(middleware.Session.Get("username"))
+	if err != nil {
+		log.Default().Printf("Error loading waitlist: %s\n", err.Error())
+	}
+	return waitlist
+}
@@ -22,6 +22,7 @@ const (
 	EnvQUERY            = "QUERY_STRING"
 	EnvPATH             = "PATH_INFO"
 	EnvCOOKIE           = "HTTP_COOKIE"
+	EnvREMOTEADDR       = "REMOTE_ADDR"
 	SessionIDCookieName = "SID"
 )
 
@@ -260,7 +261,7 @@ func (s *SessionImpl) LoadFromDB() bool {
 	dt30MinAgo := time.Now().Add(-30 * time.Minute).Format(time.RFC3339)
 	rows, err := DB.Query("SELECT label, value FROM session_entries WHERE session_id=? and updated_at > ?", s.ID, dt30MinAgo)
 	if err != nil {
-		log.Default().Panicln("error fetching rows from db: %s", err.Error())
+		log.Default().Panicf("error fetching rows from db: %s", err.Error())
 		return false
 	}
 	if rows.Err() != nil {
@@ -274,7 +275,7 @@ func (s *SessionImpl) LoadFromDB() bool {
 		var label, value string
 		err = rows.Scan(&label, &value)
 		if err != nil {
-			log.Default().Panicln("error fetching rows from db: %s", err.Error())
+			log.Default().Panicf("error fetching rows from db: %s", err.Error())
 			return false
 		}
 		s.Set(label, value)
@@ -0,0 +1,14 @@
+-- Bookable properties and rooms within them. Existing entries belong to the
+-- first property.
+CREATE TABLE resources (
+	id INT NOT NULL AUTO_INCREMENT,
+	parent_id INT NULL,
+	name VARCHAR(100) NOT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (parent_id) REFERENCES resources (id)
+);
+
+INSERT INTO resources (id, name) VALUES (1, 'Chalet');
+
+ALTER TABLE entries ADD COLUMN resource_id INT NOT NULL DEFAULT 1;
+ALTER TABLE entries ADD FOREIGN KEY (resource_id) REFERENCES resources (id);
@@ -0,0 +1,5 @@
+-- Number of beds of a resource. 0 keeps the resource exclusive: any overlap
+-- is a conflict.
+ALTER TABLE resources ADD COLUMN capacity INT NOT NULL DEFAULT 0;
+
+ALTER TABLE entries ADD COLUMN guests INT NOT NULL DEFAULT 1;
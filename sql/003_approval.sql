@@ -0,0 +1,19 @@
+-- Reservation lifecycle. Existing entries are final.
+ALTER TABLE entries ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'confirmed';
+
+-- Roles replace the hard coded admin.
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT '';
+UPDATE users SET role = 'admin' WHERE name = 'frank';
+
+-- Yearly periods in which new reservations have to be approved by an admin.
+-- Month and day are stored as MM-DD. A resource_id of NULL applies to all
+-- resources.
+CREATE TABLE approval_rules (
+	id INT NOT NULL AUTO_INCREMENT,
+	label VARCHAR(100) NOT NULL,
+	start_md CHAR(5) NOT NULL,
+	end_md CHAR(5) NOT NULL,
+	resource_id INT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (resource_id) REFERENCES resources (id)
+);
@@ -0,0 +1,24 @@
+-- Users waiting for an already booked range.
+CREATE TABLE waitlist (
+	id INT NOT NULL AUTO_INCREMENT,
+	user VARCHAR(50) NOT NULL,
+	resource_id INT NOT NULL,
+	begin DATE NOT NULL,
+	end DATE NOT NULL,
+	guests INT NOT NULL DEFAULT 1,
+	auto_hold BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at DATETIME NOT NULL,
+	notified_at DATETIME NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (resource_id) REFERENCES resources (id)
+);
+
+-- Messages shown to a user on the next visit (and mailed if configured).
+CREATE TABLE notifications (
+	id INT NOT NULL AUTO_INCREMENT,
+	user VARCHAR(50) NOT NULL,
+	message TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	read_at DATETIME NULL,
+	PRIMARY KEY (id)
+);
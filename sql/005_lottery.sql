@@ -0,0 +1,43 @@
+-- Allocation of peak periods by lottery, one round per year.
+CREATE TABLE lottery_rounds (
+	year INT NOT NULL,
+	request_open DATE NOT NULL,
+	request_close DATE NOT NULL,
+	weighted BOOLEAN NOT NULL DEFAULT FALSE,
+	seed BIGINT NULL,
+	drawn_at DATETIME NULL,
+	PRIMARY KEY (year)
+);
+
+CREATE TABLE lottery_periods (
+	id INT NOT NULL AUTO_INCREMENT,
+	year INT NOT NULL,
+	resource_id INT NOT NULL,
+	label VARCHAR(100) NOT NULL,
+	begin DATE NOT NULL,
+	end DATE NOT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (year) REFERENCES lottery_rounds (year),
+	FOREIGN KEY (resource_id) REFERENCES resources (id)
+);
+
+CREATE TABLE lottery_wishes (
+	year INT NOT NULL,
+	user VARCHAR(50) NOT NULL,
+	period_id INT NOT NULL,
+	rank_no INT NOT NULL,
+	PRIMARY KEY (year, user, period_id),
+	FOREIGN KEY (period_id) REFERENCES lottery_periods (id)
+);
+
+-- Outcome per participant. won counts the allocated periods, the history of
+-- losses raises the weight in weighted draws.
+CREATE TABLE lottery_results (
+	year INT NOT NULL,
+	user VARCHAR(50) NOT NULL,
+	weight DOUBLE NOT NULL,
+	position INT NOT NULL,
+	won INT NOT NULL,
+	explanation TEXT NOT NULL,
+	PRIMARY KEY (year, user)
+);
@@ -0,0 +1,22 @@
+-- Rotations of recurring periods between households over the years.
+-- participants is a comma separated list of household ids, periods a comma
+-- separated list of label:MM-DD:MM-DD.
+CREATE TABLE rotations (
+	id INT NOT NULL AUTO_INCREMENT,
+	name VARCHAR(100) NOT NULL,
+	resource_id INT NOT NULL,
+	start_year INT NOT NULL,
+	participants TEXT NOT NULL,
+	periods TEXT NOT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (resource_id) REFERENCES resources (id)
+);
+
+-- Years for which a rotation was turned into entries.
+CREATE TABLE rotation_years (
+	rotation_id INT NOT NULL,
+	year INT NOT NULL,
+	materialized_at DATETIME NOT NULL,
+	PRIMARY KEY (rotation_id, year),
+	FOREIGN KEY (rotation_id) REFERENCES rotations (id)
+);
@@ -0,0 +1,14 @@
+-- Families sharing bookings and quotas. Entries keep the user who made them
+-- and are attributed to the user's household at booking time.
+CREATE TABLE households (
+	id INT NOT NULL AUTO_INCREMENT,
+	name VARCHAR(100) NOT NULL,
+	color VARCHAR(7) NOT NULL DEFAULT '',
+	PRIMARY KEY (id)
+);
+
+ALTER TABLE users ADD COLUMN household_id INT NULL;
+ALTER TABLE users ADD FOREIGN KEY (household_id) REFERENCES households (id);
+
+ALTER TABLE entries ADD COLUMN household_id INT NULL;
+ALTER TABLE entries ADD FOREIGN KEY (household_id) REFERENCES households (id);
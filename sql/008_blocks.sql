@@ -0,0 +1,12 @@
+-- Periods in which a resource can not be booked at all, e.g. renovations.
+CREATE TABLE blocks (
+	id INT NOT NULL AUTO_INCREMENT,
+	resource_id INT NOT NULL,
+	begin DATE NOT NULL,
+	end DATE NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	reason VARCHAR(255) NOT NULL DEFAULT '',
+	created_by VARCHAR(50) NOT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (resource_id) REFERENCES resources (id)
+);
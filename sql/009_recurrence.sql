@@ -0,0 +1,18 @@
+-- Recurrence rules in RRULE syntax (FREQ, INTERVAL, COUNT, UNTIL) and the
+-- occurrences left out of a series.
+ALTER TABLE entries ADD COLUMN rrule VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE blocks ADD COLUMN rrule VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE TABLE entry_exceptions (
+	entry_id INT NOT NULL,
+	occurrence DATE NOT NULL,
+	PRIMARY KEY (entry_id, occurrence),
+	FOREIGN KEY (entry_id) REFERENCES entries (res_id) ON DELETE CASCADE
+);
+
+CREATE TABLE block_exceptions (
+	block_id INT NOT NULL,
+	occurrence DATE NOT NULL,
+	PRIMARY KEY (block_id, occurrence),
+	FOREIGN KEY (block_id) REFERENCES blocks (id) ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+-- Proposals to exchange entries between members or to hand one over. The
+-- rows are kept as history of who traded what.
+CREATE TABLE swaps (
+	id INT NOT NULL AUTO_INCREMENT,
+	entry_id INT NOT NULL,
+	counter_entry_id INT NULL,
+	proposer VARCHAR(50) NOT NULL,
+	recipient VARCHAR(50) NOT NULL,
+	message VARCHAR(255) NOT NULL DEFAULT '',
+	status VARCHAR(20) NOT NULL,
+	created_at DATETIME NOT NULL,
+	decided_at DATETIME NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (entry_id) REFERENCES entries (res_id) ON DELETE CASCADE,
+	FOREIGN KEY (counter_entry_id) REFERENCES entries (res_id) ON DELETE CASCADE
+);
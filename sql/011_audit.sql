@@ -0,0 +1,16 @@
+-- Append-only log of changes. The application only ever inserts and reads
+-- rows, snapshots are JSON.
+CREATE TABLE audit_log (
+	id INT NOT NULL AUTO_INCREMENT,
+	created_at DATETIME NOT NULL,
+	actor VARCHAR(50) NOT NULL,
+	ip VARCHAR(45) NOT NULL DEFAULT '',
+	action VARCHAR(50) NOT NULL,
+	object_type VARCHAR(30) NOT NULL,
+	object_id INT NOT NULL DEFAULT 0,
+	before_json TEXT NULL,
+	after_json TEXT NULL,
+	PRIMARY KEY (id),
+	KEY (actor),
+	KEY (object_type, object_id)
+);
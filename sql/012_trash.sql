@@ -0,0 +1,4 @@
+-- Deleted entries stay in the trash until they are purged after the
+-- retention period.
+ALTER TABLE entries ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE entries ADD COLUMN deleted_by VARCHAR(50) NULL;
@@ -0,0 +1,4 @@
+-- Children among the guests and the cost of an entry in cents, computed
+-- with the prices at booking time and on every change.
+ALTER TABLE entries ADD COLUMN children INT NOT NULL DEFAULT 0;
+ALTER TABLE entries ADD COLUMN cost INT NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+-- Payments into the house fund, booked against the yearly statement of a
+-- household or of a user booking without household. Treasurers have the
+-- role 'treasurer'.
+CREATE TABLE payments (
+	id INT NOT NULL AUTO_INCREMENT,
+	year INT NOT NULL,
+	user VARCHAR(50) NULL,
+	household_id INT NULL,
+	amount INT NOT NULL,
+	paid_on DATE NOT NULL,
+	note VARCHAR(255) NOT NULL DEFAULT '',
+	recorded_by VARCHAR(50) NOT NULL,
+	PRIMARY KEY (id),
+	FOREIGN KEY (household_id) REFERENCES households (id)
+);
@@ -0,0 +1,11 @@
+-- Guests staying overnight with an entry, reported for the tourist tax
+-- (Kurtaxe). Category is one of adult, youth and child.
+CREATE TABLE entry_guests (
+	id INT NOT NULL AUTO_INCREMENT,
+	entry_id INT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	category VARCHAR(10) NOT NULL DEFAULT 'adult',
+	nationality VARCHAR(2) NOT NULL DEFAULT '',
+	PRIMARY KEY (id),
+	FOREIGN KEY (entry_id) REFERENCES entries (res_id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Entries booked by a member for guests only. The guests, the member
+-- included if present, are stored in entry_guests.
+ALTER TABLE entries ADD COLUMN member_absent BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,15 @@
+-- Entries booked by someone else than the user staying, or for external
+-- guests without login. For external guests the user is the member
+-- responsible for the stay.
+ALTER TABLE entries ADD COLUMN created_by VARCHAR(50) NULL;
+ALTER TABLE entries ADD COLUMN contact_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE entries ADD COLUMN contact_info VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Members allowed to book on behalf of another user, or of external guests
+-- if principal is null.
+CREATE TABLE delegations (
+	id INT NOT NULL AUTO_INCREMENT,
+	delegate VARCHAR(50) NOT NULL,
+	principal VARCHAR(50) NULL,
+	PRIMARY KEY (id)
+);
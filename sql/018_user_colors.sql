@@ -0,0 +1,2 @@
+-- Calendar color of users without household, households have their own.
+ALTER TABLE users ADD COLUMN color VARCHAR(7) NOT NULL DEFAULT '';
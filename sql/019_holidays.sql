@@ -0,0 +1,12 @@
+-- Holidays imported from ICS, shown in the calendar. End is the last day.
+-- Source names an import, which is replaced by importing it again.
+CREATE TABLE holidays (
+	id INT NOT NULL AUTO_INCREMENT,
+	name VARCHAR(255) NOT NULL,
+	begin DATE NOT NULL,
+	end DATE NOT NULL,
+	kind VARCHAR(10) NOT NULL,
+	source VARCHAR(100) NOT NULL,
+	PRIMARY KEY (id),
+	INDEX (source)
+);
@@ -0,0 +1,5 @@
+-- Entries booked before their user joined a household count for it like the
+-- household's own bookings, see SetHousehold.
+UPDATE entries e JOIN users u ON u.name = e.user
+SET e.household_id = u.household_id
+WHERE e.household_id IS NULL AND u.household_id IS NOT NULL;